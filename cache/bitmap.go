@@ -5,7 +5,6 @@ import (
 	"github.com/phper95/pkg/errors"
 	"github.com/phper95/pkg/timeutil"
 	"github.com/go-redis/redis/v7"
-	"go.uber.org/zap"
 	"strings"
 	"time"
 )
@@ -63,7 +62,7 @@ func (r *Redis) GetBit(key string, offset int64) (value int64, err error) {
 		r.trace.Key = realKey
 		r.trace.Value = fmt.Sprintf("origin : %d ; real: %d ", offset, GetOffset(offset))
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -104,7 +103,7 @@ func (r *Redis) GetBigBit(key string, offset int64) (value int64, err error) {
 		r.trace.Key = realKey
 		r.trace.Value = fmt.Sprintf("origin : %d ; real: %d ", offset, GetBigOffset(offset))
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -148,7 +147,7 @@ func (r *Redis) SetBit(key string, offset int64, val int) (value int64, err erro
 		r.trace.Key = realKey
 		r.trace.Value = val
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -191,7 +190,7 @@ func (r *Redis) SetBigBit(key string, offset int64, val int) (value int64, err e
 		r.trace.Key = realKey
 		r.trace.Value = val
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -229,7 +228,7 @@ func (r *Redis) GetBitNOBucket(key string, offset int64) (value int64, err error
 		r.trace.Key = key
 		r.trace.Value = offset
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -267,7 +266,7 @@ func (r *Redis) BitCountNOBucket(key string, start, end int64) (value int64, err
 		r.trace.Key = key
 		r.trace.Value = fmt.Sprintf("start : %d ; end : %d", start, end)
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -312,7 +311,7 @@ func (r *Redis) SetBitNOBucket(key string, offset int64, val int) (value int64,
 		r.trace.Key = key
 		r.trace.Value = val
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -351,7 +350,7 @@ func (r *Redis) BitOPNOBucket(op, destKey string, keys ...string) (value int64,
 		r.trace.Key = destKey
 		r.trace.Value = strings.Join(keys, ",")
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	var cmd *redis.IntCmd
@@ -28,6 +28,11 @@ type Cache interface {
 	Version() string
 }
 
+// NXSetter key 不存在时才写入，可用于分布式锁、请求去重等，*Redis 实现了该接口
+type NXSetter interface {
+	SetNX(key string, value interface{}, ttl time.Duration) (bool, error)
+}
+
 type stdLogger interface {
 	Print(v ...interface{})
 	Printf(format string, v ...interface{})
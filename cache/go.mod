@@ -6,6 +6,7 @@ require (
 	github.com/go-redis/redis/v7 v7.4.1
 	github.com/phper95/pkg/compression v0.0.0-20230517145757-27be2fc31eea
 	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/logger v0.0.0-20230517145757-27be2fc31eea
 	github.com/phper95/pkg/timeutil v0.0.0-20230517145757-27be2fc31eea
 	github.com/phper95/pkg/trace v0.0.0-20230517145757-27be2fc31eea
 	github.com/stretchr/testify v1.7.0
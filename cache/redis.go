@@ -1,9 +1,11 @@
 package cache
 
 import (
+	"context"
 	"fmt"
 	"github.com/go-redis/redis/v7"
 	"github.com/phper95/pkg/errors"
+	"github.com/phper95/pkg/logger"
 	"github.com/phper95/pkg/timeutil"
 	"github.com/phper95/pkg/trace"
 	"go.uber.org/zap"
@@ -18,6 +20,7 @@ type Redis struct {
 	client        *redis.Client
 	clusterClient *redis.ClusterClient
 	trace         *trace.Cache
+	ctx           context.Context
 }
 
 const (
@@ -134,6 +137,23 @@ func GetRedisClusterClient(name string) *Redis {
 	return nil
 }
 
+// WithContext 返回使用 ctx 的 Redis 副本，日志会带上 ctx 中的 trace ID 等字段
+func (r *Redis) WithContext(ctx context.Context) *Redis {
+	rc := *r
+	rc.ctx = ctx
+	return &rc
+}
+
+// traceFields 返回 redis-trace 日志字段，包括 context 中的日志字段
+func (r *Redis) traceFields() []zap.Field {
+	return append([]zap.Field{zap.Any("", r.trace)}, logger.ContextFields(r.ctx)...)
+}
+
+// logger 返回带有 context 中日志字段的 cache 模块 logger
+func (r *Redis) logger() *zap.Logger {
+	return logger.ModuleFromContext(r.ctx, "cache")
+}
+
 // Set set some <key,value> into redis
 func (r *Redis) Set(key string, value interface{}, ttl time.Duration) error {
 	if len(key) == 0 {
@@ -155,7 +175,7 @@ func (r *Redis) Set(key string, value interface{}, ttl time.Duration) error {
 		r.trace.Value = value
 		r.trace.TTL = ttl.Minutes()
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -172,10 +192,48 @@ func (r *Redis) Set(key string, value interface{}, ttl time.Duration) error {
 	return nil
 }
 
+// SetNX set some <key,value> into redis only if the key does not exist
+func (r *Redis) SetNX(key string, value interface{}, ttl time.Duration) (ok bool, err error) {
+	if len(key) == 0 {
+		return false, errors.New("empty key")
+	}
+	ts := time.Now()
+	defer func() {
+		if r.trace == nil || r.trace.Logger == nil {
+			return
+		}
+		costMillisecond := time.Since(ts).Milliseconds()
+
+		if !r.trace.AlwaysTrace && costMillisecond < r.trace.SlowLoggerMillisecond {
+			return
+		}
+		r.trace.TraceTime = timeutil.CSTLayoutString()
+		r.trace.CMD = "setnx"
+		r.trace.Key = key
+		r.trace.Value = value
+		r.trace.TTL = ttl.Minutes()
+		r.trace.CostMillisecond = costMillisecond
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
+	}()
+
+	if r.client != nil {
+		if ok, err = r.client.SetNX(key, value, ttl).Result(); err != nil {
+			return false, errors.Wrapf(err, "redis setnx key: %s err", key)
+		}
+		return
+	}
+
+	//集群版
+	if ok, err = r.clusterClient.SetNX(key, value, ttl).Result(); err != nil {
+		return false, errors.Wrapf(err, "redis setnx key: %s err", key)
+	}
+	return
+}
+
 // Get get some key from redis
 func (r *Redis) Get(key string) interface{} {
 	if len(key) == 0 {
-		CacheStdLogger.Println("empty key")
+		r.logger().Warn("empty key")
 		return nil
 	}
 	ts := time.Now()
@@ -193,13 +251,13 @@ func (r *Redis) Get(key string) interface{} {
 		r.trace.Key = key
 		r.trace.Value = ""
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
 		value, err := r.client.Get(key).Result()
 		if err != nil && err != redis.Nil {
-			CacheStdLogger.Printf("redis get key: %s err %v", key, err)
+			r.logger().Error("redis get err", zap.String("key", key), zap.Error(err))
 
 		}
 		return value
@@ -207,7 +265,7 @@ func (r *Redis) Get(key string) interface{} {
 
 	value, err := r.clusterClient.Get(key).Result()
 	if err != nil && err != redis.Nil {
-		CacheStdLogger.Printf("redis get key: %s err %v", key, err)
+		r.logger().Error("redis get err", zap.String("key", key), zap.Error(err))
 	}
 	return value
 }
@@ -232,7 +290,7 @@ func (r *Redis) GetStr(key string) (value string, err error) {
 		r.trace.Key = key
 		r.trace.Value = value
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -316,13 +374,13 @@ func (r *Redis) IsExist(key string) bool {
 	if r.client != nil {
 		value, err := r.client.Exists(key).Result()
 		if err != nil && err != redis.Nil {
-			CacheStdLogger.Printf("cmd : Exists ; key : %s ; err : %v", key, err)
+			r.logger().Error("redis exists err", zap.String("key", key), zap.Error(err))
 		}
 		return value > 0
 	}
 	value, err := r.clusterClient.Exists(key).Result()
 	if err != nil && err != redis.Nil {
-		CacheStdLogger.Printf("cmd : Exists ; key : %s ; err : %v", key, err)
+		r.logger().Error("redis exists err", zap.String("key", key), zap.Error(err))
 	}
 	return value > 0
 }
@@ -348,7 +406,7 @@ func (r *Redis) Delete(key string) error {
 		r.trace.Key = key
 		r.trace.Value = strconv.FormatInt(value, 10)
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 
 	if r.client != nil {
@@ -380,7 +438,7 @@ func (r *Redis) Incr(key string) (value int64, err error) {
 		r.trace.Key = key
 		r.trace.Value = strconv.FormatInt(value, 10)
 		r.trace.CostMillisecond = costMillisecond
-		r.trace.Logger.Warn("redis-trace", zap.Any("", r.trace))
+		r.trace.Logger.Warn("redis-trace", r.traceFields()...)
 	}()
 	if r.client != nil {
 		value, err = r.client.Incr(key).Result()
@@ -1,13 +1,18 @@
 package cache
 
 import (
+	"context"
 	"github.com/phper95/pkg/compression"
+	"github.com/phper95/pkg/logger"
+	"github.com/phper95/pkg/trace"
 	"github.com/go-redis/redis/v7"
 	"github.com/stretchr/testify/assert"
 	"testing"
 	"time"
 )
 
+var _ NXSetter = (*Redis)(nil)
+
 type UserTest struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
@@ -78,3 +83,17 @@ func TestBitOp(t *testing.T) {
 	err = redisClient.Delete(key)
 	t.Log(res, err)
 }
+
+func TestRedisWithContext(t *testing.T) {
+	r := &Redis{trace: &trace.Cache{}}
+	ctx := logger.WithTraceID(context.Background(), "trace-1")
+	rc := r.WithContext(ctx)
+	assert.Nil(t, r.ctx)
+	assert.Equal(t, ctx, rc.ctx)
+
+	fields := rc.traceFields()
+	assert.Len(t, fields, 2)
+	assert.Equal(t, logger.TraceIDKey, fields[1].Key)
+	assert.Equal(t, "trace-1", fields[1].String)
+	assert.Len(t, r.traceFields(), 1)
+}
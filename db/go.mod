@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/logger v0.0.0-20230517145757-27be2fc31eea
 	go.uber.org/zap v1.21.0
 	gorm.io/driver/mysql v1.3.2
 	gorm.io/gorm v1.23.2
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"github.com/phper95/pkg/errors"
+	"github.com/phper95/pkg/logger"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/utils"
+	"time"
+)
+
+// gormLogger gorm 的日志，通过 logger.ModuleFromContext 带上 context 中的 trace ID 等字段
+type gormLogger struct {
+	level                     gormlogger.LogLevel
+	slowThreshold             time.Duration
+	ignoreRecordNotFoundError bool
+}
+
+func newGormLogger(level gormlogger.LogLevel, slowThreshold time.Duration) gormlogger.Interface {
+	return &gormLogger{level: level, slowThreshold: slowThreshold, ignoreRecordNotFoundError: true}
+}
+
+// sqlLogger 返回带有 context 中日志字段的 db 模块 logger
+func sqlLogger(ctx context.Context) *zap.Logger {
+	return logger.ModuleFromContext(ctx, DefaultLogName)
+}
+
+func (l *gormLogger) LogMode(level gormlogger.LogLevel) gormlogger.Interface {
+	newLogger := *l
+	newLogger.level = level
+	return &newLogger
+}
+
+func (l *gormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Info {
+		sqlLogger(ctx).Sugar().Infof(msg, data...)
+	}
+}
+
+func (l *gormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Warn {
+		sqlLogger(ctx).Sugar().Warnf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if l.level >= gormlogger.Error {
+		sqlLogger(ctx).Sugar().Errorf(msg, data...)
+	}
+}
+
+func (l *gormLogger) Trace(ctx context.Context, begin time.Time, fc func() (sql string, rowsAffected int64), err error) {
+	if l.level <= gormlogger.Silent {
+		return
+	}
+
+	elapsed := time.Since(begin)
+	fields := func() []zap.Field {
+		sql, rows := fc()
+		return []zap.Field{
+			zap.String("sql", sql),
+			zap.Int64("rows", rows),
+			zap.Duration("elapsed", elapsed),
+			zap.String("file", utils.FileWithLineNum()),
+		}
+	}
+
+	switch {
+	case err != nil && l.level >= gormlogger.Error && (!errors.Is(err, gorm.ErrRecordNotFound) || !l.ignoreRecordNotFoundError):
+		sqlLogger(ctx).Error("sql error", append(fields(), zap.Error(err))...)
+	case l.slowThreshold > 0 && elapsed > l.slowThreshold && l.level >= gormlogger.Warn:
+		sqlLogger(ctx).Warn("slow sql", append(fields(), zap.Duration("threshold", l.slowThreshold))...)
+	case l.level == gormlogger.Info:
+		sqlLogger(ctx).Info("sql", fields()...)
+	}
+}
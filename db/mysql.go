@@ -3,6 +3,7 @@ package db
 import (
 	"fmt"
 	"github.com/phper95/pkg/errors"
+	"go.uber.org/zap"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -188,12 +189,8 @@ func dbConnect(user, pass, host, dbName string, option *option) (*gorm.DB, error
 	if option.SlowLogMillisecond == 0 {
 		option.SlowLogMillisecond = DefaultSlowLogMillisecond
 	}
-	Log := logger.New(MysqltdLogger, logger.Config{
-		SlowThreshold:             time.Duration(option.SlowLogMillisecond) * time.Millisecond,
-		LogLevel:                  logger.Warn,
-		IgnoreRecordNotFoundError: true,
-		Colorful:                  true,
-	})
+	// 日志带上 context 中的 trace ID 等字段，需要通过 db.WithContext(ctx) 传入 context
+	Log := newGormLogger(logger.Warn, time.Duration(option.SlowLogMillisecond)*time.Millisecond)
 
 	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{
 		//为了确保数据一致性，GORM 会在事务里执行写入操作（创建、更新、删除）
@@ -260,12 +257,12 @@ func dbConnect(user, pass, host, dbName string, option *option) (*gorm.DB, error
 
 func afterLog(db *gorm.DB) {
 	err := db.Error
-	//ctx := db.Statement.Context
+	ctx := db.Statement.Context
 	sql := db.Dialector.Explain(db.Statement.SQL.String(), db.Statement.Vars...)
 	if err != nil {
-		MysqltdLogger.Print(sql, err)
+		sqlLogger(ctx).Error("[ SQL语句 ]", zap.String("sql", sql), zap.Error(err))
 	} else {
-		fmt.Println("[ SQL语句 ]", sql)
+		sqlLogger(ctx).Info("[ SQL语句 ]", zap.String("sql", sql))
 	}
 
 }
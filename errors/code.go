@@ -0,0 +1,262 @@
+package errors
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// gRPC 状态码，与 google.golang.org/grpc/codes 一致，使用时 codes.Code(c.GRPCCode())
+const (
+	GRPCOK                 uint32 = 0
+	GRPCCanceled           uint32 = 1
+	GRPCUnknown            uint32 = 2
+	GRPCInvalidArgument    uint32 = 3
+	GRPCDeadlineExceeded   uint32 = 4
+	GRPCNotFound           uint32 = 5
+	GRPCAlreadyExists      uint32 = 6
+	GRPCPermissionDenied   uint32 = 7
+	GRPCResourceExhausted  uint32 = 8
+	GRPCFailedPrecondition uint32 = 9
+	GRPCUnimplemented      uint32 = 12
+	GRPCInternal           uint32 = 13
+	GRPCUnavailable        uint32 = 14
+	GRPCUnauthenticated    uint32 = 16
+)
+
+// Internal 未注册错误码的 error 使用的错误码
+var Internal = &Code{Code: http.StatusInternalServerError, HTTPStatus: http.StatusInternalServerError, Message: "internal server error", I18nKey: "error.internal"}
+
+// Code 业务错误码
+type Code struct {
+	Code       int    // 业务码
+	HTTPStatus int    // HTTP 状态码
+	Message    string // 给用户看的提示信息
+	I18nKey    string // 提示信息的多语言 key
+}
+
+// New 创建带有业务码的 error，detail 为内部错误详情，不会返回给用户
+func (c *Code) New(detail string) Error {
+	return &item{msg: detail, code: c, stack: callers()}
+}
+
+// Newf 创建带有业务码的 error
+func (c *Code) Newf(format string, args ...interface{}) Error {
+	return &item{msg: fmt.Sprintf(format, args...), code: c, stack: callers()}
+}
+
+// Wrap 包装 err 并设置业务码，err 为 nil 时返回 nil
+func (c *Code) Wrap(err error, detail string) Error {
+	if err == nil {
+		return nil
+	}
+
+	e := wrap(err, detail)
+	e.code = c
+	return e
+}
+
+// Is 判断 err 的业务码是否为 c
+func (c *Code) Is(err error) bool {
+	return CodeOf(err) == c
+}
+
+// GRPCCode 按 HTTP 状态码对应的 gRPC 状态码
+func (c *Code) GRPCCode() uint32 {
+	switch c.HTTPStatus {
+	case http.StatusOK:
+		return GRPCOK
+	case http.StatusBadRequest:
+		return GRPCInvalidArgument
+	case http.StatusUnauthorized:
+		return GRPCUnauthenticated
+	case http.StatusForbidden:
+		return GRPCPermissionDenied
+	case http.StatusNotFound:
+		return GRPCNotFound
+	case http.StatusConflict:
+		return GRPCAlreadyExists
+	case http.StatusPreconditionFailed:
+		return GRPCFailedPrecondition
+	case http.StatusTooManyRequests:
+		return GRPCResourceExhausted
+	case 499:
+		return GRPCCanceled
+	case http.StatusNotImplemented:
+		return GRPCUnimplemented
+	case http.StatusServiceUnavailable:
+		return GRPCUnavailable
+	case http.StatusGatewayTimeout:
+		return GRPCDeadlineExceeded
+	case http.StatusInternalServerError:
+		return GRPCInternal
+	default:
+		return GRPCUnknown
+	}
+}
+
+// Localize 返回 lang 语言的提示信息，没有设置 Translator 或没有翻译时返回 Message
+func (c *Code) Localize(lang string) string {
+	translatorMux.RLock()
+	t := translator
+	translatorMux.RUnlock()
+
+	if t != nil && c.I18nKey != "" {
+		if msg, ok := t(c.I18nKey, lang); ok {
+			return msg
+		}
+	}
+	return c.Message
+}
+
+// Translator 根据多语言 key 和语言返回提示信息
+type Translator func(key, lang string) (string, bool)
+
+var (
+	translatorMux sync.RWMutex
+	translator    Translator
+)
+
+// SetTranslator 设置提示信息的翻译方法
+func SetTranslator(t Translator) {
+	translatorMux.Lock()
+	defer translatorMux.Unlock()
+
+	translator = t
+}
+
+// CodeOf 返回 err 链上最外层的业务码，没有业务码时返回 Internal，err 为 nil 时返回 nil
+func CodeOf(err error) *Code {
+	if err == nil {
+		return nil
+	}
+
+	for e := err; e != nil; e = Unwrap(e) {
+		if i, ok := e.(*item); ok && i.code != nil {
+			return i.code
+		}
+	}
+	return Internal
+}
+
+// Registry 业务码注册表，用于检查业务码是否重复
+type Registry struct {
+	mux   sync.RWMutex
+	codes map[int]*Code
+}
+
+// NewRegistry 创建业务码注册表
+func NewRegistry() *Registry {
+	return &Registry{codes: make(map[int]*Code)}
+}
+
+// Register 注册业务码，业务码重复时返回 error
+func (r *Registry) Register(code, httpStatus int, message, i18nKey string) (*Code, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	if exist, ok := r.codes[code]; ok {
+		return nil, Errorf("error code %d already registered: %s", code, exist.Message)
+	}
+
+	c := &Code{Code: code, HTTPStatus: httpStatus, Message: message, I18nKey: i18nKey}
+	r.codes[code] = c
+	return c, nil
+}
+
+// MustRegister 注册业务码，业务码重复时 panic，用于包级变量初始化
+func (r *Registry) MustRegister(code, httpStatus int, message, i18nKey string) *Code {
+	c, err := r.Register(code, httpStatus, message, i18nKey)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Lookup 根据业务码查找
+func (r *Registry) Lookup(code int) (*Code, bool) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	c, ok := r.codes[code]
+	return c, ok
+}
+
+// Codes 返回按业务码排序的所有业务码，可用于生成错误码文档
+func (r *Registry) Codes() []*Code {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	codes := make([]*Code, 0, len(r.codes))
+	for _, c := range r.codes {
+		codes = append(codes, c)
+	}
+	sort.Slice(codes, func(i, j int) bool { return codes[i].Code < codes[j].Code })
+	return codes
+}
+
+// defaultRegistry 默认的业务码注册表
+var defaultRegistry = NewRegistry()
+
+// Register 在默认注册表中注册业务码
+func Register(code, httpStatus int, message, i18nKey string) (*Code, error) {
+	return defaultRegistry.Register(code, httpStatus, message, i18nKey)
+}
+
+// MustRegister 在默认注册表中注册业务码，重复时 panic
+func MustRegister(code, httpStatus int, message, i18nKey string) *Code {
+	return defaultRegistry.MustRegister(code, httpStatus, message, i18nKey)
+}
+
+// Lookup 在默认注册表中查找业务码
+func Lookup(code int) (*Code, bool) {
+	return defaultRegistry.Lookup(code)
+}
+
+// ErrorBody 标准的 json 错误响应
+type ErrorBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Detail  string `json:"detail,omitempty"`
+}
+
+// NewErrorBody 返回 err 对应的 HTTP 状态码和错误响应，withDetail 为 true 时返回内部错误详情(仅用于调试)；
+// err 为 nil 时返回 http.StatusOK 和 nil
+func NewErrorBody(err error, lang string, withDetail bool) (int, *ErrorBody) {
+	if err == nil {
+		return http.StatusOK, nil
+	}
+
+	c := CodeOf(err)
+	body := &ErrorBody{Code: c.Code, Message: c.Localize(lang)}
+	if withDetail {
+		body.Detail = err.Error()
+	}
+	return c.HTTPStatus, body
+}
+
+// WriteJSON 按 err 的业务码写入 json 错误响应，语言从 Accept-Language 中获取；
+// err 为 nil 时不写入任何内容，由调用方写入正常响应
+func WriteJSON(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		return
+	}
+
+	lang := ""
+	if r != nil {
+		// zh-CN,zh;q=0.9 取第一个语言
+		lang = r.Header.Get("Accept-Language")
+		if i := strings.IndexAny(lang, ",;"); i >= 0 {
+			lang = lang[:i]
+		}
+		lang = strings.TrimSpace(lang)
+	}
+
+	status, body := NewErrorBody(err, lang, false)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
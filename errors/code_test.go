@@ -0,0 +1,51 @@
+package errors
+
+import (
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCode(t *testing.T) {
+	registry := NewRegistry()
+	notFound := registry.MustRegister(10404, http.StatusNotFound, "user not found", "error.user_not_found")
+	if _, err := registry.Register(10404, http.StatusNotFound, "dup", ""); err == nil {
+		t.Fatal("duplicate code should be rejected")
+	}
+
+	err := Wrap(notFound.Wrap(sql.ErrNoRows, "uid: 1"), "get user")
+	if CodeOf(err) != notFound || !notFound.Is(err) || !Is(err, sql.ErrNoRows) {
+		t.Fatalf("unexpected code %v", CodeOf(err))
+	}
+	if notFound.GRPCCode() != GRPCNotFound || CodeOf(New("other")) != Internal || CodeOf(nil) != nil {
+		t.Fatal("unexpected code mapping")
+	}
+
+	SetTranslator(func(key, lang string) (string, bool) {
+		if key == "error.user_not_found" && lang == "zh-CN" {
+			return "用户不存在", true
+		}
+		return "", false
+	})
+	defer SetTranslator(nil)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Language", "zh-CN,zh;q=0.9")
+	w := httptest.NewRecorder()
+	WriteJSON(w, r, err)
+	if w.Code != http.StatusNotFound || strings.TrimSpace(w.Body.String()) != `{"code":10404,"message":"用户不存在"}` {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// nil error 不写入错误响应
+	if status, body := NewErrorBody(nil, "", true); status != http.StatusOK || body != nil {
+		t.Fatalf("unexpected body %d %v", status, body)
+	}
+	w = httptest.NewRecorder()
+	WriteJSON(w, r, nil)
+	if w.Body.Len() != 0 || w.Header().Get("Content-Type") != "" {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
@@ -1,10 +1,10 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 	"github.com/pkg/errors"
 	"io"
-	"log"
 	"runtime"
 )
 
@@ -20,17 +20,37 @@ type Error interface {
 }
 
 type item struct {
-	msg   string
-	stack []uintptr
+	msg    string
+	cause  error
+	code   *Code
+	fields map[string]interface{}
+	stack  []uintptr
 }
 
 func (i *item) Error() string {
-	return i.msg
+	msg := i.msg
+	if msg == "" && i.code != nil {
+		msg = i.code.Message
+	}
+
+	switch {
+	case i.cause == nil:
+		return msg
+	case msg == "":
+		return i.cause.Error()
+	default:
+		return msg + "; " + i.cause.Error()
+	}
+}
+
+// Unwrap 返回被包装的 error，用于标准库 errors.Is/As
+func (i *item) Unwrap() error {
+	return i.cause
 }
 
 // Format used by go.uber.org/zap in Verbose
 func (i *item) Format(s fmt.State, verb rune) {
-	io.WriteString(s, i.msg)
+	io.WriteString(s, i.Error())
 	io.WriteString(s, "\n")
 
 	for _, pc := range i.stack {
@@ -48,19 +68,27 @@ func Errorf(format string, args ...interface{}) Error {
 	return &item{msg: fmt.Sprintf(format, args...), stack: callers()}
 }
 
+// wrap 返回包装了 err 的新 error，不修改 err；err 带有调用栈时沿用其调用栈
+func wrap(err error, msg string) *item {
+	var e *item
+	if As(err, &e) {
+		return &item{msg: msg, cause: err, stack: e.stack}
+	}
+
+	stack := callers()
+	if len(stack) > 0 {
+		stack = stack[1:] // 跳过 Wrap/Wrapf
+	}
+	return &item{msg: msg, cause: err, stack: stack}
+}
+
 // Wrap with some extra message into err
 func Wrap(err error, msg string) Error {
 	if err == nil {
 		return nil
 	}
 
-	e, ok := err.(*item)
-	if !ok {
-		return &item{msg: fmt.Sprintf("%s; %s", msg, err.Error()), stack: callers()}
-	}
-
-	e.msg = fmt.Sprintf("%s; %s", msg, e.msg)
-	return e
+	return wrap(err, msg)
 }
 
 // Wrapf with some extra message into err
@@ -69,15 +97,7 @@ func Wrapf(err error, format string, args ...interface{}) Error {
 		return nil
 	}
 
-	msg := fmt.Sprintf(format, args...)
-
-	e, ok := err.(*item)
-	if !ok {
-		return &item{msg: fmt.Sprintf("%s; %s", msg, err.Error()), stack: callers()}
-	}
-
-	e.msg = fmt.Sprintf("%s; %s", msg, e.msg)
-	return e
+	return wrap(err, fmt.Sprintf(format, args...))
 }
 
 // WithStack add caller stack information
@@ -90,21 +110,38 @@ func WithStack(err error) Error {
 		return e
 	}
 
-	return &item{msg: err.Error(), stack: callers()}
+	return &item{cause: err, stack: callers()}
 }
 
-func Recover() {
-	e := recover()
-	if e != nil {
-		s := Stack(2)
-		log.Fatal("Panic: %v\nTraceback\r:%s", e, string(s))
+// Cause 返回最内层的原始 error，兼容 github.com/pkg/errors 的 Cause
+func Cause(err error) error {
+	for err != nil {
+		var next error
+		switch e := err.(type) {
+		case interface{ Unwrap() error }:
+			next = e.Unwrap()
+		case interface{ Cause() error }:
+			next = e.Cause()
+		}
+		if next == nil {
+			return err
+		}
+		err = next
 	}
+	return err
 }
 
-func RecoverStackWithoutLF() {
-	e := recover()
-	if e != nil {
-		s := StackWithoutLF(3)
-		log.Fatal("Panic: %v Traceback:%s", e, string(s))
-	}
+// Is 同标准库 errors.Is
+func Is(err, target error) bool {
+	return stderrors.Is(err, target)
+}
+
+// As 同标准库 errors.As
+func As(err error, target interface{}) bool {
+	return stderrors.As(err, target)
+}
+
+// Unwrap 同标准库 errors.Unwrap
+func Unwrap(err error) error {
+	return stderrors.Unwrap(err)
 }
@@ -3,6 +3,7 @@ package errors
 import (
 	"errors"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"testing"
 )
 
@@ -31,3 +32,44 @@ func TestErr(t *testing.T) {
 
 	t.Logf("%+v", New("a dummy error"))
 }
+
+func TestWrapCause(t *testing.T) {
+	cause := errors.New("no rows")
+	err := Wrapf(Wrap(cause, "query user"), "uid: %d", 1)
+
+	if err.Error() != "uid: 1; query user; no rows" {
+		t.Fatalf("unexpected message %s", err.Error())
+	}
+	if !Is(err, cause) || !errors.Is(err, cause) || Cause(err) != cause {
+		t.Fatal("cause should be kept")
+	}
+
+	base := New("timeout")
+	wrapped := Wrap(base, "ping")
+	if base.Error() != "timeout" || wrapped.Error() != "ping; timeout" {
+		t.Fatalf("wrap should not mutate the original error: %s, %s", base, wrapped)
+	}
+
+	var target *item
+	if !As(wrapped, &target) || Unwrap(wrapped) != base || Cause(WithStack(cause)) != cause {
+		t.Fatal("unexpected unwrap result")
+	}
+}
+
+func TestFields(t *testing.T) {
+	err := WithFields(New("pay failed"), "uid", 1, "order_id", "o1")
+	err = WithFields(Wrap(err, "checkout"), "uid", 2)
+
+	fields := Fields(err)
+	if len(fields) != 2 || fields["uid"] != 2 || fields["order_id"] != "o1" || err.Error() != "checkout; pay failed" {
+		t.Fatalf("unexpected fields %v %s", fields, err)
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	if err := err.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err != nil {
+		t.Fatal(err)
+	}
+	if enc.Fields["msg"] != "checkout; pay failed" || enc.Fields["fields"].(map[string]interface{})["uid"] != 2 || enc.Fields["stack"] == "" {
+		t.Fatalf("unexpected log object %v", enc.Fields)
+	}
+}
@@ -0,0 +1,83 @@
+package errors
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
+	"sort"
+	"strings"
+)
+
+// WithFields 给 err 附加结构化字段，如用户 ID、订单号，kv 为 key、value 交替出现；
+// 包装后的 error 会合并各层字段，外层字段覆盖内层同名字段
+func WithFields(err error, kv ...interface{}) Error {
+	if err == nil {
+		return nil
+	}
+
+	e := wrap(err, "")
+	e.fields = make(map[string]interface{}, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		e.fields[fmt.Sprint(kv[i])] = kv[i+1]
+	}
+	return e
+}
+
+// Fields 返回 err 链上合并后的字段，外层字段覆盖内层同名字段
+func Fields(err error) map[string]interface{} {
+	var chain []*item
+	for e := err; e != nil; e = Unwrap(e) {
+		if i, ok := e.(*item); ok && len(i.fields) > 0 {
+			chain = append(chain, i)
+		}
+	}
+	if len(chain) == 0 {
+		return nil
+	}
+
+	fields := make(map[string]interface{})
+	for i := len(chain) - 1; i >= 0; i-- {
+		for k, v := range chain[i].fields {
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// Fields 返回合并后的字段，logger.WrapMeta 通过该方法获取字段
+func (i *item) Fields() map[string]interface{} {
+	return Fields(i)
+}
+
+// MarshalLogObject 实现 zapcore.ObjectMarshaler，zap.Any("error", err) 时输出 msg、code、fields、stack
+func (i *item) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("msg", i.Error())
+	if c := CodeOf(i); c != Internal {
+		enc.AddInt("code", c.Code)
+	}
+
+	if fields := i.Fields(); len(fields) > 0 {
+		enc.AddObject("fields", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			keys := make([]string, 0, len(fields))
+			for k := range fields {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			for _, k := range keys {
+				if err := enc.AddReflected(k, fields[k]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}))
+	}
+
+	if len(i.stack) > 0 {
+		var b strings.Builder
+		for _, pc := range i.stack {
+			fmt.Fprintf(&b, "%+v\n", errors.Frame(pc))
+		}
+		enc.AddString("stack", b.String())
+	}
+	return nil
+}
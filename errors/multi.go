@@ -0,0 +1,118 @@
+package errors
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxSummaryGroups Error() 中最多展示的不同错误数
+const maxSummaryGroups = 5
+
+// IndexedError 批量操作中第 Index 项的错误
+type IndexedError struct {
+	Index int
+	Err   error
+}
+
+// MultiError 批量操作的多个错误，支持 errors.Is/As 匹配其中任一错误
+type MultiError struct {
+	errs []IndexedError
+}
+
+// NewMultiError 创建 MultiError
+func NewMultiError() *MultiError {
+	return &MultiError{}
+}
+
+// Add 添加第 index 项的错误，err 为 nil 时忽略
+func (m *MultiError) Add(index int, err error) {
+	if err == nil {
+		return
+	}
+	m.errs = append(m.errs, IndexedError{Index: index, Err: err})
+}
+
+// Len 错误数
+func (m *MultiError) Len() int {
+	if m == nil {
+		return 0
+	}
+	return len(m.errs)
+}
+
+// Errors 返回所有错误
+func (m *MultiError) Errors() []IndexedError {
+	if m == nil {
+		return nil
+	}
+	return m.errs
+}
+
+// ErrorOrNil 没有错误时返回 nil，避免返回非 nil 的空 MultiError
+func (m *MultiError) ErrorOrNil() error {
+	if m.Len() == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error 按错误信息分组的摘要，如：3 errors: [0 2] duplicate key; [5] timeout
+func (m *MultiError) Error() string {
+	if m.Len() == 0 {
+		return "no errors"
+	}
+
+	var msgs []string
+	indexes := make(map[string][]int)
+	for _, e := range m.errs {
+		msg := e.Err.Error()
+		if _, ok := indexes[msg]; !ok {
+			msgs = append(msgs, msg)
+		}
+		indexes[msg] = append(indexes[msg], e.Index)
+	}
+
+	var b strings.Builder
+	if len(m.errs) == 1 {
+		b.WriteString("1 error: ")
+	} else {
+		fmt.Fprintf(&b, "%d errors: ", len(m.errs))
+	}
+	for i, msg := range msgs {
+		if i == maxSummaryGroups {
+			fmt.Fprintf(&b, "; and %d more", len(msgs)-maxSummaryGroups)
+			break
+		}
+		if i > 0 {
+			b.WriteString("; ")
+		}
+		fmt.Fprintf(&b, "%v %s", indexes[msg], msg)
+	}
+	return b.String()
+}
+
+// Is 任一错误匹配 target 时返回 true
+func (m *MultiError) Is(target error) bool {
+	if m == nil {
+		return false
+	}
+	for _, e := range m.errs {
+		if Is(e.Err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 找到第一个可以转换为 target 的错误
+func (m *MultiError) As(target interface{}) bool {
+	if m == nil {
+		return false
+	}
+	for _, e := range m.errs {
+		if As(e.Err, target) {
+			return true
+		}
+	}
+	return false
+}
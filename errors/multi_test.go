@@ -0,0 +1,37 @@
+package errors
+
+import (
+	"database/sql"
+	"testing"
+)
+
+type indexErr struct{ id string }
+
+func (e *indexErr) Error() string { return "duplicate key" }
+
+func TestMultiError(t *testing.T) {
+	m := NewMultiError()
+	if m.ErrorOrNil() != nil {
+		t.Fatal("empty MultiError should be nil")
+	}
+
+	m.Add(0, &indexErr{id: "a"})
+	m.Add(1, nil)
+	m.Add(2, &indexErr{id: "b"})
+	m.Add(5, Wrap(sql.ErrNoRows, "query"))
+
+	err := m.ErrorOrNil()
+	if err.Error() != "3 errors: [0 2] duplicate key; [5] query; sql: no rows in result set" {
+		t.Fatalf("unexpected summary %s", err.Error())
+	}
+
+	var target *indexErr
+	if !Is(err, sql.ErrNoRows) || !As(err, &target) || target.id != "a" {
+		t.Fatal("members should match Is/As")
+	}
+
+	var nilMulti *MultiError
+	if nilMulti.Is(sql.ErrNoRows) || nilMulti.As(&target) {
+		t.Fatal("nil MultiError should not match")
+	}
+}
@@ -0,0 +1,192 @@
+package errors
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go.uber.org/zap"
+	"log"
+	"net/http"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Reporter 处理 panic 转换成的 error，如打印日志、统计监控、发送告警
+type Reporter func(err Error)
+
+var (
+	reportersMux sync.RWMutex
+	reporters    []Reporter
+
+	// panics recover 到的 panic 次数
+	panics uint64
+)
+
+// RegisterReporter 注册 panic 的处理方法，没有注册时使用标准库 log 打印
+func RegisterReporter(r Reporter) {
+	reportersMux.Lock()
+	defer reportersMux.Unlock()
+
+	reporters = append(reporters, r)
+}
+
+// PanicCount 返回 recover 到的 panic 次数，可用于监控
+func PanicCount() uint64 {
+	return atomic.LoadUint64(&panics)
+}
+
+// LogReporter 使用 zap 打印 panic，输出 msg、fields、stack
+func LogReporter(logger *zap.Logger) Reporter {
+	return func(err Error) {
+		logger.Error("panic recovered", zap.Any("error", err))
+	}
+}
+
+// WebhookReporter 异步 POST json 格式的 panic 信息到 url，用于告警
+func WebhookReporter(url string) Reporter {
+	client := &http.Client{Timeout: 5 * time.Second}
+
+	return func(err Error) {
+		body := struct {
+			Error  string                 `json:"error"`
+			Fields map[string]interface{} `json:"fields,omitempty"`
+			Stack  string                 `json:"stack"`
+			Time   string                 `json:"time"`
+		}{
+			Error:  err.Error(),
+			Fields: Fields(err),
+			Stack:  fmt.Sprintf("%+v", err),
+			Time:   time.Now().Format(time.RFC3339),
+		}
+		raw, _ := json.Marshal(body)
+
+		go func() {
+			resp, err := client.Post(url, "application/json; charset=utf-8", bytes.NewReader(raw))
+			if err != nil {
+				log.Printf("panic webhook report err: %v", err)
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+}
+
+// RecoverOption recover 的配置
+type RecoverOption func(*recoverOption)
+
+type recoverOption struct {
+	crash  bool
+	fields []interface{}
+	errp   *error
+}
+
+// WithCrash 处理完 panic 后重新 panic，使进程退出
+func WithCrash() RecoverOption {
+	return func(opt *recoverOption) {
+		opt.crash = true
+	}
+}
+
+// WithRecoverFields 给 panic 转换成的 error 附加字段，如任务名
+func WithRecoverFields(kv ...interface{}) RecoverOption {
+	return func(opt *recoverOption) {
+		opt.fields = append(opt.fields, kv...)
+	}
+}
+
+// WithRecoverError panic 转换成的 error 写入 errp，用于函数通过命名返回值返回 panic
+func WithRecoverError(errp *error) RecoverOption {
+	return func(opt *recoverOption) {
+		opt.errp = errp
+	}
+}
+
+// FromPanic 把 recover() 的返回值转换成带调用栈的 error
+func FromPanic(v interface{}) Error {
+	return fromPanic(v, 3)
+}
+
+func fromPanic(v interface{}, skip int) Error {
+	var pcs [32]uintptr
+	stack := pcs[:runtime.Callers(skip, pcs[:])]
+
+	if err, ok := v.(error); ok {
+		return &item{msg: "panic", cause: err, stack: stack}
+	}
+	return &item{msg: fmt.Sprintf("panic: %v", v), stack: stack}
+}
+
+// handlePanic 转换 panic 并通知所有 Reporter
+func handlePanic(v interface{}, opts []RecoverOption, withoutLF bool) {
+	opt := new(recoverOption)
+	for _, f := range opts {
+		if f != nil {
+			f(opt)
+		}
+	}
+
+	atomic.AddUint64(&panics, 1)
+
+	// 跳过 runtime.Callers、fromPanic、handlePanic、Recover
+	err := fromPanic(v, 5)
+	if len(opt.fields) > 0 {
+		err = WithFields(err, opt.fields...)
+	}
+	if opt.errp != nil {
+		*opt.errp = err
+	}
+
+	reportersMux.RLock()
+	rs := reporters
+	reportersMux.RUnlock()
+
+	if len(rs) == 0 {
+		if withoutLF {
+			log.Printf("Panic: %v Traceback:%s", v, StackWithoutLF(4))
+		} else {
+			log.Printf("Panic: %v\nTraceback:\n%s", v, Stack(3))
+		}
+	}
+	for _, r := range rs {
+		report(r, err)
+	}
+
+	if opt.crash {
+		panic(v)
+	}
+}
+
+// report 调用 Reporter，Reporter 本身 panic 时不影响其它 Reporter
+func report(r Reporter, err Error) {
+	defer func() {
+		if e := recover(); e != nil {
+			log.Printf("panic reporter panic: %v", e)
+		}
+	}()
+	r(err)
+}
+
+// Recover 捕获 panic 并转换成 error 通知 Reporter，默认继续运行，WithCrash 时重新 panic；
+// 必须直接 defer 调用：defer errors.Recover()
+func Recover(opts ...RecoverOption) {
+	if e := recover(); e != nil {
+		handlePanic(e, opts, false)
+	}
+}
+
+// RecoverStackWithoutLF 与 Recover 相同，没有注册 Reporter 时打印的调用栈不换行
+func RecoverStackWithoutLF(opts ...RecoverOption) {
+	if e := recover(); e != nil {
+		handlePanic(e, opts, true)
+	}
+}
+
+// SafeGo 启动 goroutine 执行 fn，fn panic 时按 opts 处理，默认不会导致进程退出
+func SafeGo(fn func(), opts ...RecoverOption) {
+	go func() {
+		defer Recover(opts...)
+		fn()
+	}()
+}
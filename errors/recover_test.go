@@ -0,0 +1,48 @@
+package errors
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRecover(t *testing.T) {
+	var reported []Error
+	var mux sync.Mutex
+	RegisterReporter(func(err Error) {
+		mux.Lock()
+		reported = append(reported, err)
+		mux.Unlock()
+	})
+	defer func() { reporters = nil }()
+
+	f := func() (err error) {
+		defer Recover(WithRecoverError(&err), WithRecoverFields("task", "sync"))
+		panic("boom")
+	}
+	err := f()
+	if err == nil || err.Error() != "panic: boom" || Fields(err)["task"] != "sync" {
+		t.Fatalf("unexpected err %v", err)
+	}
+	mux.Lock()
+	if len(reported) != 1 || reported[0].Error() != "panic: boom" {
+		t.Fatalf("reporter should be called, got %v", reported)
+	}
+	mux.Unlock()
+
+	done := make(chan struct{})
+	SafeGo(func() {
+		defer close(done)
+		panic(New("goroutine boom"))
+	})
+	<-done
+
+	defer func() {
+		if e := recover(); e == nil {
+			t.Fatal("WithCrash should panic again")
+		}
+	}()
+	func() {
+		defer Recover(WithCrash())
+		panic("crash")
+	}()
+}
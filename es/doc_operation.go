@@ -3,6 +3,7 @@ package es
 import (
 	"context"
 	"github.com/olivere/elastic/v7"
+	"github.com/phper95/pkg/errors"
 )
 
 const (
@@ -67,6 +68,26 @@ func (c *Client) BulkCreate(indexName, id, routing string, doc interface{}) {
 	c.BulkProcessor.Add(bulkCreateRequest)
 }
 
+// BulkError 把批量操作中失败的文档转换为 *errors.MultiError，Index 为文档在请求中的下标，没有失败时返回 nil；
+// BulkCreateDocs 等批量方法部分文档失败时 error 仍为 nil，需要按文档检查错误时对返回的 BulkResponse 调用
+func BulkError(resp *elastic.BulkResponse) error {
+	if resp == nil || !resp.Errors {
+		return nil
+	}
+
+	multiErr := errors.NewMultiError()
+	for i, item := range resp.Items {
+		for _, result := range item {
+			if result.Error == nil {
+				continue
+			}
+			multiErr.Add(i, errors.WithFields(errors.Errorf("%s: %s", result.Error.Type, result.Error.Reason),
+				"id", result.Id, "status", result.Status))
+		}
+	}
+	return multiErr.ErrorOrNil()
+}
+
 // 批量的方式新建一批文档,实时提交
 func (c *Client) BulkCreateDocs(ctx context.Context, indexName string, docs []*BulkCreateDoc) (*elastic.BulkResponse, error) {
 	bulkService := c.Client.Bulk().ErrorTrace(true)
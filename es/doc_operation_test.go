@@ -0,0 +1,33 @@
+package es
+
+import (
+	"github.com/olivere/elastic/v7"
+	"github.com/phper95/pkg/errors"
+	"testing"
+)
+
+func TestBulkError(t *testing.T) {
+	if BulkError(nil) != nil || BulkError(&elastic.BulkResponse{}) != nil {
+		t.Fatal("expect nil error")
+	}
+
+	resp := &elastic.BulkResponse{
+		Errors: true,
+		Items: []map[string]*elastic.BulkResponseItem{
+			{"create": {Id: "1", Status: 201}},
+			{"create": {Id: "2", Status: 409, Error: &elastic.ErrorDetails{Type: "version_conflict_engine_exception", Reason: "document already exists"}}},
+			{"create": {Id: "3", Status: 201}},
+			{"create": {Id: "4", Status: 400, Error: &elastic.ErrorDetails{Type: "mapper_parsing_exception", Reason: "failed to parse"}}},
+		},
+	}
+
+	multiErr, ok := BulkError(resp).(*errors.MultiError)
+	if !ok || multiErr.Len() != 2 {
+		t.Fatalf("unexpected error %v", multiErr)
+	}
+	errs := multiErr.Errors()
+	if errs[0].Index != 1 || errs[1].Index != 3 ||
+		errs[0].Err.Error() != "version_conflict_engine_exception: document already exists" {
+		t.Fatalf("unexpected errors %v", errs)
+	}
+}
@@ -2,4 +2,9 @@ module github.com/phper95/pkg/es
 
 go 1.16
 
-require github.com/olivere/elastic/v7 v7.0.32
+require (
+	github.com/olivere/elastic/v7 v7.0.32
+	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/logger v0.0.0-20230517145757-27be2fc31eea
+	go.uber.org/zap v1.21.0
+)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"encoding/json"
 	"github.com/olivere/elastic/v7"
+	"github.com/phper95/pkg/logger"
+	"go.uber.org/zap"
 	"io"
 	"strings"
 )
@@ -160,10 +162,11 @@ func (c *Client) Query(ctx context.Context, indexName string, routings []string,
 	data, _ := json.Marshal(src)
 	rs := strings.Join(routings, ",")
 	if c.DebugMode || c.QueryLogEnable || queryOpt.EnableDSL {
-		EStdLogger.Print("DSL : ", string(data), "routing: ", rs)
+		logger.ModuleFromContext(ctx, "es").Info("es query", zap.ByteString("dsl", data), zap.String("routing", rs))
 	}
 	if queryOpt.SlowQueryMillisecond > 0 && res != nil && res.TookInMillis >= queryOpt.SlowQueryMillisecond {
-		EStdLogger.Print("slow query DSL : ", string(data), "routing: ", rs)
+		logger.ModuleFromContext(ctx, "es").Warn("es slow query", zap.ByteString("dsl", data), zap.String("routing", rs),
+			zap.Int64("took_ms", res.TookInMillis))
 	}
 	return res, err
 
@@ -198,7 +201,7 @@ func (c *Client) ScrollQuery(ctx context.Context, index []string, typeStr string
 	data, _ := json.Marshal(src)
 	rs := strings.Join(routings, ",")
 	if c.DebugMode || c.QueryLogEnable || queryOpt.EnableDSL {
-		EStdLogger.Print("DSL : ", string(data), "routing: ", rs)
+		logger.ModuleFromContext(ctx, "es").Info("es query", zap.ByteString("dsl", data), zap.String("routing", rs))
 	}
 	scrollService := c.Client.Scroll(index...).SearchSource(searchSource).Size(size).Preference(DefaultPreference)
 	if len(routings) > 0 {
@@ -218,14 +221,15 @@ func (c *Client) ScrollQuery(ctx context.Context, index []string, typeStr string
 			break
 		}
 		if queryOpt.SlowQueryMillisecond > 0 && res != nil && res.TookInMillis >= queryOpt.SlowQueryMillisecond {
-			EStdLogger.Print("slow query DSL : ", string(data), "routing: ", rs)
+			logger.ModuleFromContext(ctx, "es").Warn("es slow query", zap.ByteString("dsl", data), zap.String("routing", rs),
+				zap.Int64("took_ms", res.TookInMillis))
 		}
 		if res == nil {
-			EStdLogger.Print("nil results !")
+			logger.ModuleFromContext(ctx, "es").Warn("es scroll got nil results")
 			break
 		}
 		if res.Hits == nil {
-			EStdLogger.Print("expected results.Hits != nil; got nil")
+			logger.ModuleFromContext(ctx, "es").Warn("es scroll got nil hits")
 		}
 
 		if len(res.Hits.Hits) == 0 {
@@ -0,0 +1,204 @@
+package httpclient
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/phper95/pkg/errors"
+	"github.com/phper95/pkg/sign"
+	"net/http"
+	httpURL "net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 提前10秒刷新即将过期的 token
+	tokenExpiryDelta = 10 * time.Second
+)
+
+// AuthProvider 为请求附加认证信息，配合 WithAuth 使用
+type AuthProvider interface {
+	// Authorize 为请求设置认证信息，每次请求(包括重试)都会调用
+	Authorize(req *http.Request, payload []byte) error
+
+	// Refresh 请求返回 401 后刷新凭证，返回 nil 时使用新凭证重试一次
+	Refresh(ctx context.Context) error
+}
+
+var errNotRefreshable = errors.New("static credentials can not be refreshed")
+
+type bearerAuth struct {
+	token string
+}
+
+// NewBearerAuth 固定的 Bearer token
+func NewBearerAuth(token string) AuthProvider {
+	return &bearerAuth{token: token}
+}
+
+func (a *bearerAuth) Authorize(req *http.Request, payload []byte) error {
+	req.Header.Set("Authorization", "Bearer "+a.token)
+	return nil
+}
+
+func (a *bearerAuth) Refresh(ctx context.Context) error {
+	return errNotRefreshable
+}
+
+type basicAuth struct {
+	username string
+	password string
+}
+
+// NewBasicAuth HTTP Basic 认证
+func NewBasicAuth(username, password string) AuthProvider {
+	return &basicAuth{username: username, password: password}
+}
+
+func (a *basicAuth) Authorize(req *http.Request, payload []byte) error {
+	req.SetBasicAuth(a.username, a.password)
+	return nil
+}
+
+func (a *basicAuth) Refresh(ctx context.Context) error {
+	return errNotRefreshable
+}
+
+// oauth2Token token 接口的返回
+type oauth2Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+type clientCredentials struct {
+	mux          sync.Mutex
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       []string
+	token        string
+	tokenType    string
+	expiry       time.Time
+}
+
+// NewClientCredentialsAuth OAuth2 client credentials 模式，token 会被缓存，过期前自动刷新
+func NewClientCredentialsAuth(tokenURL, clientID, clientSecret string, scopes ...string) AuthProvider {
+	return &clientCredentials{
+		tokenURL:     tokenURL,
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		scopes:       scopes,
+	}
+}
+
+func (a *clientCredentials) Authorize(req *http.Request, payload []byte) error {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if a.token == "" || time.Now().Add(tokenExpiryDelta).After(a.expiry) {
+		if err := a.fetch(req.Context()); err != nil {
+			return err
+		}
+	}
+
+	req.Header.Set("Authorization", a.tokenType+" "+a.token)
+	return nil
+}
+
+func (a *clientCredentials) Refresh(ctx context.Context) error {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	return a.fetch(ctx)
+}
+
+func (a *clientCredentials) fetch(ctx context.Context) error {
+	form := httpURL.Values{}
+	form.Set("grant_type", "client_credentials")
+	form.Set("client_id", a.clientID)
+	form.Set("client_secret", a.clientSecret)
+	if len(a.scopes) > 0 {
+		form.Set("scope", strings.Join(a.scopes, " "))
+	}
+
+	options := []Option{WithOnFailedRetry(1, 0, nil)}
+	if deadline, ok := ctx.Deadline(); ok {
+		options = append(options, WithTTL(time.Until(deadline)))
+	}
+
+	_, body, err := PostForm(a.tokenURL, form, options...)
+	if err != nil {
+		return errors.Wrapf(err, "fetch token from `%s` err", a.tokenURL)
+	}
+
+	token := new(oauth2Token)
+	if err = json.Unmarshal(body, token); err != nil {
+		return errors.Wrap(err, "unmarshal token err")
+	}
+	if token.AccessToken == "" {
+		return errors.New("empty access_token")
+	}
+
+	a.token = token.AccessToken
+	a.tokenType = "Bearer"
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		a.tokenType = token.TokenType
+	}
+	a.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	if token.ExpiresIn <= 0 {
+		a.expiry = time.Now().Add(time.Hour)
+	}
+	return nil
+}
+
+type signAuth struct {
+	signature sign.Signature
+}
+
+// NewSignAuth 使用 sign.Signature 对请求签名，设置 Authorization 和 Date header；
+// 签名参数为 query 参数，form 请求还包含 body 中的参数；实现了 sign.RequestSignature 时 body 摘要和 header 按 sign.Option 签名
+func NewSignAuth(signature sign.Signature) AuthProvider {
+	return &signAuth{signature: signature}
+}
+
+func (a *signAuth) Authorize(req *http.Request, payload []byte) error {
+	params := req.URL.Query()
+	if strings.HasPrefix(req.Header.Get("Content-Type"), "application/x-www-form-urlencoded") && len(payload) > 0 {
+		form, err := httpURL.ParseQuery(string(payload))
+		if err != nil {
+			return errors.Wrap(err, "parse form body err")
+		}
+		for key, values := range form {
+			for _, value := range values {
+				params.Add(key, value)
+			}
+		}
+	}
+
+	var authorization, date string
+	var err error
+	if signature, ok := a.signature.(sign.RequestSignature); ok {
+		authorization, date, err = signature.GenerateRequest(&sign.Request{
+			Path:   req.URL.Path,
+			Method: req.Method,
+			Params: params,
+			Header: req.Header,
+			Body:   payload,
+		})
+	} else {
+		authorization, date, err = a.signature.Generate(req.URL.Path, req.Method, params)
+	}
+	if err != nil {
+		return errors.Wrap(err, "generate signature err")
+	}
+
+	req.Header.Set("Authorization", authorization)
+	req.Header.Set("Date", date)
+	return nil
+}
+
+func (a *signAuth) Refresh(ctx context.Context) error {
+	return errNotRefreshable
+}
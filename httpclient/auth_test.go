@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"fmt"
+	"github.com/phper95/pkg/sign"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientCredentialsAuth(t *testing.T) {
+	var issued int32
+	tokenServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.PostForm.Get("grant_type") != "client_credentials" || r.PostForm.Get("client_secret") != "secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		fmt.Fprintf(w, `{"access_token":"t%d","token_type":"bearer","expires_in":3600}`, atomic.AddInt32(&issued, 1))
+	}))
+	defer tokenServer.Close()
+
+	// 只接受第二次签发的 token，模拟 token 被提前吊销
+	apiServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t2" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer apiServer.Close()
+
+	auth := NewClientCredentialsAuth(tokenServer.URL, "client", "secret")
+	for i := 0; i < 2; i++ {
+		_, body, err := Get(apiServer.URL, nil, WithAuth(auth))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "ok" {
+			t.Fatalf("unexpected body %q", body)
+		}
+	}
+	if atomic.LoadInt32(&issued) != 2 {
+		t.Fatalf("expect 2 tokens issued, got %d", issued)
+	}
+}
+
+func TestSignAuth(t *testing.T) {
+	signature := sign.New("AK100523687952", "W1WTYvJpfeH1YpUjTpeFbEx^DnpQ&35L", time.Minute)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		ok, err := signature.Verify(r.Header.Get("Authorization"), r.Header.Get("Date"), r.URL.Path, r.Method, r.Form)
+		if err != nil || !ok {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	// 只实现 sign.Signature 时使用 Generate 签名
+	legacy := struct{ sign.Signature }{signature}
+	for _, s := range []sign.Signature{signature, legacy} {
+		_, body, err := PostForm(server.URL+"/echo?a=a1", map[string][]string{"c": {"c1 c2"}}, WithAuth(NewSignAuth(s)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "ok" {
+			t.Fatalf("unexpected body %q", body)
+		}
+	}
+}
@@ -0,0 +1,437 @@
+package httpclient
+
+import (
+	"context"
+	"github.com/phper95/pkg/errors"
+	"github.com/sony/gobreaker"
+	"hash/crc32"
+	"net/http"
+	httpURL "net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// RoundRobin 轮询
+	RoundRobin Strategy = iota
+	// LeastPending 选择进行中请求数最少的地址
+	LeastPending
+	// ConsistentHash 按 hash key 一致性哈希，相同的 key 落在同一个地址上
+	ConsistentHash
+)
+
+const (
+	// DefaultRefreshInterval 默认每30秒重新进行一次服务发现
+	DefaultRefreshInterval = 30 * time.Second
+	// DefaultHealthCheckInterval 默认每10秒进行一次健康检查
+	DefaultHealthCheckInterval = 10 * time.Second
+	// DefaultHealthCheckTimeout 健康检查最长执行2秒
+	DefaultHealthCheckTimeout = 2 * time.Second
+
+	// 一致性哈希中每个地址的虚拟节点数
+	virtualNodes = 100
+)
+
+// Strategy 负载均衡策略
+type Strategy int
+
+// BalancerOption 自定义设置负载均衡
+type BalancerOption func(*balancerOption)
+
+type balancerOption struct {
+	strategy            Strategy
+	refreshInterval     time.Duration
+	healthPath          string
+	healthInterval      time.Duration
+	breakerFailures     uint32
+	breakerOpenPeriod   time.Duration
+	breakerHalfOpenReqs uint32
+}
+
+// WithBalancerStrategy 设置负载均衡策略，默认 RoundRobin
+func WithBalancerStrategy(strategy Strategy) BalancerOption {
+	return func(opt *balancerOption) {
+		opt.strategy = strategy
+	}
+}
+
+// WithBalancerRefresh 设置重新进行服务发现的间隔，小于等于 0 时使用 DefaultRefreshInterval
+func WithBalancerRefresh(interval time.Duration) BalancerOption {
+	return func(opt *balancerOption) {
+		opt.refreshInterval = interval
+	}
+}
+
+// WithBalancerHealthCheck 定时 GET 每个地址的 path，返回码不是 200 的地址会被摘除，直到恢复；
+// interval 小于等于 0 时使用 DefaultHealthCheckInterval
+func WithBalancerHealthCheck(path string, interval time.Duration) BalancerOption {
+	return func(opt *balancerOption) {
+		opt.healthPath = path
+		opt.healthInterval = interval
+	}
+}
+
+// WithBalancerBreaker 为每个地址设置断路器，连续失败 failures 次后摘除该地址，
+// 经过 openPeriod 后进入半开状态，放行 halfOpenRequests 个请求进行探测
+func WithBalancerBreaker(failures uint32, openPeriod time.Duration, halfOpenRequests uint32) BalancerOption {
+	return func(opt *balancerOption) {
+		opt.breakerFailures = failures
+		opt.breakerOpenPeriod = openPeriod
+		opt.breakerHalfOpenReqs = halfOpenRequests
+	}
+}
+
+type endpoint struct {
+	pending int64
+	addr    string
+	base    *httpURL.URL
+	healthy int32
+	breaker *gobreaker.TwoStepCircuitBreaker
+}
+
+func (e *endpoint) available() bool {
+	if atomic.LoadInt32(&e.healthy) == 0 {
+		return false
+	}
+	return e.breaker == nil || e.breaker.State() != gobreaker.StateOpen
+}
+
+// rewrite 将请求地址的 scheme 和 host 替换为该地址
+func (e *endpoint) rewrite(rawURL string) (string, error) {
+	target, err := httpURL.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrapf(err, "parse rawURL `%s` err", rawURL)
+	}
+
+	target.Scheme = e.base.Scheme
+	target.Host = e.base.Host
+	if target.Path != "" && !strings.HasPrefix(target.Path, "/") {
+		target.Path = "/" + target.Path
+	}
+	target.Path = strings.TrimSuffix(e.base.Path, "/") + target.Path
+	target.RawPath = ""
+	return target.String(), nil
+}
+
+type ringNode struct {
+	hash     uint32
+	endpoint *endpoint
+}
+
+// Balancer 客户端负载均衡，配合 WithBalancer 使用
+type Balancer struct {
+	next          uint64 // 64 位对齐，供 atomic 使用
+	refreshFailed uint64
+	mux           sync.RWMutex
+	resolver      Resolver
+	opt           *balancerOption
+	endpoints     []*endpoint
+	ring          []ringNode
+	exit          chan struct{}
+	closeOnce     sync.Once
+}
+
+// NewBalancer 创建负载均衡，创建时会同步进行一次服务发现
+func NewBalancer(resolver Resolver, options ...BalancerOption) (*Balancer, error) {
+	if resolver == nil {
+		return nil, errors.New("resolver required")
+	}
+
+	opt := &balancerOption{strategy: RoundRobin, refreshInterval: DefaultRefreshInterval}
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	if opt.refreshInterval <= 0 {
+		opt.refreshInterval = DefaultRefreshInterval
+	}
+	if opt.healthInterval <= 0 {
+		opt.healthInterval = DefaultHealthCheckInterval
+	}
+
+	b := &Balancer{
+		resolver: resolver,
+		opt:      opt,
+		exit:     make(chan struct{}),
+	}
+	if err := b.refresh(); err != nil {
+		return nil, err
+	}
+	if opt.healthPath != "" {
+		b.healthCheck()
+	}
+
+	go b.loop()
+	return b, nil
+}
+
+// Endpoints 返回当前所有地址以及是否可用
+func (b *Balancer) Endpoints() map[string]bool {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	endpoints := make(map[string]bool, len(b.endpoints))
+	for _, e := range b.endpoints {
+		endpoints[e.addr] = e.available()
+	}
+	return endpoints
+}
+
+// RefreshFailed 定时服务发现失败的次数，失败时继续使用上一次的地址
+func (b *Balancer) RefreshFailed() uint64 {
+	return atomic.LoadUint64(&b.refreshFailed)
+}
+
+// Close 停止服务发现和健康检查
+func (b *Balancer) Close() {
+	b.closeOnce.Do(func() {
+		close(b.exit)
+	})
+}
+
+func (b *Balancer) loop() {
+	refresh := time.NewTicker(b.opt.refreshInterval)
+	defer refresh.Stop()
+
+	var health <-chan time.Time
+	if b.opt.healthPath != "" {
+		ticker := time.NewTicker(b.opt.healthInterval)
+		defer ticker.Stop()
+		health = ticker.C
+	}
+
+	for {
+		select {
+		case <-b.exit:
+			return
+		case <-refresh.C:
+			if err := b.refresh(); err != nil {
+				atomic.AddUint64(&b.refreshFailed, 1)
+			}
+		case <-health:
+			b.healthCheck()
+		}
+	}
+}
+
+func (b *Balancer) refresh() error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.opt.refreshInterval)
+	defer cancel()
+
+	addrs, err := b.resolver.Resolve(ctx)
+	if err != nil {
+		return errors.Wrap(err, "resolve endpoints err")
+	}
+	if len(addrs) == 0 {
+		return errors.New("no endpoint resolved")
+	}
+
+	b.mux.RLock()
+	existing := make(map[string]*endpoint, len(b.endpoints))
+	for _, e := range b.endpoints {
+		existing[e.addr] = e
+	}
+	b.mux.RUnlock()
+
+	endpoints := make([]*endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		if !strings.Contains(addr, "://") {
+			addr = "http://" + addr
+		}
+
+		if e, ok := existing[addr]; ok {
+			endpoints = append(endpoints, e)
+			continue
+		}
+
+		base, err := httpURL.Parse(addr)
+		if err != nil {
+			return errors.Wrapf(err, "parse endpoint `%s` err", addr)
+		}
+
+		e := &endpoint{addr: addr, base: base, healthy: 1}
+		if b.opt.breakerFailures > 0 {
+			e.breaker = b.newBreaker(addr)
+		}
+		endpoints = append(endpoints, e)
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		return endpoints[i].addr < endpoints[j].addr
+	})
+
+	ring := make([]ringNode, 0, len(endpoints)*virtualNodes)
+	if b.opt.strategy == ConsistentHash {
+		for _, e := range endpoints {
+			for i := 0; i < virtualNodes; i++ {
+				ring = append(ring, ringNode{hash: crc32.ChecksumIEEE([]byte(e.addr + "#" + strconv.Itoa(i))), endpoint: e})
+			}
+		}
+		sort.Slice(ring, func(i, j int) bool {
+			return ring[i].hash < ring[j].hash
+		})
+	}
+
+	b.mux.Lock()
+	b.endpoints = endpoints
+	b.ring = ring
+	b.mux.Unlock()
+	return nil
+}
+
+func (b *Balancer) newBreaker(addr string) *gobreaker.TwoStepCircuitBreaker {
+	openPeriod := b.opt.breakerOpenPeriod
+	if openPeriod <= 0 {
+		openPeriod = time.Minute
+	}
+	failures := b.opt.breakerFailures
+
+	return gobreaker.NewTwoStepCircuitBreaker(gobreaker.Settings{
+		Name:        addr,
+		MaxRequests: b.opt.breakerHalfOpenReqs,
+		Timeout:     openPeriod,
+		ReadyToTrip: func(counts gobreaker.Counts) bool {
+			return counts.ConsecutiveFailures >= failures
+		},
+	})
+}
+
+func (b *Balancer) healthCheck() {
+	b.mux.RLock()
+	endpoints := b.endpoints
+	b.mux.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, e := range endpoints {
+		wg.Add(1)
+		go func(e *endpoint) {
+			defer wg.Done()
+
+			healthy := int32(0)
+			if checkHealth(e, b.opt.healthPath) {
+				healthy = 1
+			}
+			atomic.StoreInt32(&e.healthy, healthy)
+		}(e)
+	}
+	wg.Wait()
+}
+
+func checkHealth(e *endpoint, path string) bool {
+	target, err := e.rewrite(path)
+	if err != nil {
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), DefaultHealthCheckTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return false
+	}
+
+	resp, err := DefaultClient.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+// candidates 按负载均衡策略返回地址的尝试顺序
+func (b *Balancer) candidates(key string) []*endpoint {
+	b.mux.RLock()
+	defer b.mux.RUnlock()
+
+	n := len(b.endpoints)
+	if n == 0 {
+		return nil
+	}
+
+	candidates := make([]*endpoint, 0, n)
+	switch b.opt.strategy {
+	case ConsistentHash:
+		hash := crc32.ChecksumIEEE([]byte(key))
+		start := sort.Search(len(b.ring), func(i int) bool {
+			return b.ring[i].hash >= hash
+		})
+		seen := make(map[*endpoint]bool, n)
+		for i := 0; i < len(b.ring) && len(candidates) < n; i++ {
+			e := b.ring[(start+i)%len(b.ring)].endpoint
+			if !seen[e] {
+				seen[e] = true
+				candidates = append(candidates, e)
+			}
+		}
+
+	default:
+		offset := int(atomic.AddUint64(&b.next, 1) % uint64(n))
+		for i := 0; i < n; i++ {
+			candidates = append(candidates, b.endpoints[(offset+i)%n])
+		}
+
+		// 进行中请求数相同时按轮询顺序
+		if b.opt.strategy == LeastPending {
+			sort.SliceStable(candidates, func(i, j int) bool {
+				return atomic.LoadInt64(&candidates[i].pending) < atomic.LoadInt64(&candidates[j].pending)
+			})
+		}
+	}
+	return candidates
+}
+
+// pick 选择一个可用地址，返回的 done 在请求结束后调用
+func (b *Balancer) pick(key string) (*endpoint, func(success bool), error) {
+	for _, e := range b.candidates(key) {
+		if !e.available() {
+			continue
+		}
+
+		var breakerDone func(success bool)
+		if e.breaker != nil {
+			var err error
+			if breakerDone, err = e.breaker.Allow(); err != nil {
+				continue
+			}
+		}
+
+		atomic.AddInt64(&e.pending, 1)
+		return e, func(success bool) {
+			atomic.AddInt64(&e.pending, -1)
+			if breakerDone != nil {
+				breakerDone(success)
+			}
+		}, nil
+	}
+
+	return nil, nil, errors.New("no available endpoint")
+}
+
+// doBalanced 通过负载均衡选择地址后发起请求
+func doBalanced(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
+	key := opt.hashKey
+	if key == "" {
+		key = url
+	}
+
+	e, done, err := opt.balancer.pick(key)
+	if err != nil {
+		return nil, _StatusNoEndpointErr, errors.Wrapf(err, "do request [%s %s] err", method, url)
+	}
+
+	target, err := e.rewrite(url)
+	if err != nil {
+		done(true)
+		return nil, -1, err
+	}
+
+	body, httpCode, err := roundTrip(ctx, method, target, payload, opt)
+	// 只有网络错误和 5xx 才认为是地址不可用
+	done(err == nil || (httpCode > 0 && httpCode < http.StatusInternalServerError))
+	return body, httpCode, err
+}
@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newNamedServer(name string, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(name))
+	}))
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	a := newNamedServer("a", http.StatusOK)
+	defer a.Close()
+	b := newNamedServer("b", http.StatusOK)
+	defer b.Close()
+
+	balancer, err := NewBalancer(StaticResolver{a.URL, b.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer balancer.Close()
+
+	hits := make(map[string]int)
+	for i := 0; i < 10; i++ {
+		_, body, err := Get("/ping", nil, WithBalancer(balancer))
+		if err != nil {
+			t.Fatal(err)
+		}
+		hits[string(body)]++
+	}
+	if hits["a"] != 5 || hits["b"] != 5 {
+		t.Fatalf("unexpected distribution %v", hits)
+	}
+}
+
+func TestBalancerBreaker(t *testing.T) {
+	ok := newNamedServer("ok", http.StatusOK)
+	defer ok.Close()
+	bad := newNamedServer("bad", http.StatusInternalServerError)
+	defer bad.Close()
+
+	balancer, err := NewBalancer(StaticResolver{ok.URL, bad.URL}, WithBalancerBreaker(2, time.Minute, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer balancer.Close()
+
+	for i := 0; i < 6; i++ {
+		Get("/ping", nil, WithBalancer(balancer), WithOnFailedRetry(1, 0, nil))
+	}
+	if balancer.Endpoints()[bad.URL] {
+		t.Fatalf("expect %s ejected", bad.URL)
+	}
+
+	for i := 0; i < 4; i++ {
+		if _, body, err := Get("/ping", nil, WithBalancer(balancer)); err != nil || string(body) != "ok" {
+			t.Fatalf("unexpected response %q %v", body, err)
+		}
+	}
+}
+
+func TestBalancerConsistentHash(t *testing.T) {
+	a := newNamedServer("a", http.StatusOK)
+	defer a.Close()
+	b := newNamedServer("b", http.StatusOK)
+	defer b.Close()
+
+	balancer, err := NewBalancer(StaticResolver{a.URL, b.URL}, WithBalancerStrategy(ConsistentHash))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer balancer.Close()
+
+	_, first, _ := Get("/ping", nil, WithBalancer(balancer), WithHashKey("user-1"))
+	for i := 0; i < 5; i++ {
+		if _, body, _ := Get("/ping", nil, WithBalancer(balancer), WithHashKey("user-1")); string(body) != string(first) {
+			t.Fatalf("expect sticky endpoint %q, got %q", first, body)
+		}
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "endpoints")
+	ioutil.WriteFile(file, []byte("# user service\nhttp://10.0.0.1:8080\n\n10.0.0.2:8080\n"), 0666)
+
+	endpoints, err := NewFileResolver(file).Resolve(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(endpoints) != 2 || endpoints[1] != "10.0.0.2:8080" {
+		t.Fatalf("unexpected endpoints %v", endpoints)
+	}
+}
+
+func TestBalancerRefreshFailed(t *testing.T) {
+	a := newNamedServer("a", http.StatusOK)
+	defer a.Close()
+
+	file := filepath.Join(t.TempDir(), "endpoints")
+	ioutil.WriteFile(file, []byte(a.URL), 0666)
+
+	balancer, err := NewBalancer(NewFileResolver(file), WithBalancerRefresh(10*time.Millisecond), WithBalancerHealthCheck("/ping", 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer balancer.Close()
+
+	os.Remove(file)
+	time.Sleep(50 * time.Millisecond)
+	if balancer.RefreshFailed() == 0 || !balancer.Endpoints()[a.URL] {
+		t.Fatalf("unexpected failed %d endpoints %v", balancer.RefreshFailed(), balancer.Endpoints())
+	}
+
+	// 间隔小于等于 0 时使用默认值
+	zero, err := NewBalancer(StaticResolver{a.URL}, WithBalancerRefresh(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zero.Close()
+}
@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/phper95/pkg/errors"
+	"github.com/phper95/pkg/logger"
 	"github.com/phper95/pkg/trace"
 	"go.uber.org/zap"
 	"io/ioutil"
@@ -44,6 +45,14 @@ func Delete(url string, form httpURL.Values, options ...Option) (httpCode int, b
 }
 
 func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
+	opt.rawURL = url
+	if hedgeable(method, opt) {
+		return doHedged(ctx, method, url, payload, opt)
+	}
+	return doAttempt(ctx, method, url, payload, opt)
+}
+
+func roundTrip(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
 	ts := time.Now()
 
 	if mock := opt.mock; mock != nil {
@@ -58,6 +67,10 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 		return mock(), http.StatusOK, nil
 	}
 
+	if fixtures := opt.fixtures; fixtures != nil && fixtures.mode == FixtureReplay {
+		return replayHTTP(ctx, method, opt.fixtureURL(url), payload, opt)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
 	if err != nil {
 		return nil, -1, errors.Wrapf(err, "new request [%s %s] err", method, url)
@@ -67,9 +80,18 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 		req.Header.Set(key, value[0])
 	}
 
+	if opt.auth != nil {
+		if err = opt.auth.Authorize(req, payload); err != nil {
+			return nil, -1, errors.Wrapf(err, "authorize request [%s %s] err", method, url)
+		}
+	}
+
 	resp, err := DefaultClient.Do(req)
 	if err != nil {
 		err = errors.Wrapf(err, "do request [%s %s] err", method, url)
+		if opt.fixtures != nil {
+			recordHTTP(method, opt.fixtureURL(url), payload, nil, nil, time.Since(ts), err, opt)
+		}
 		if opt.dialog != nil {
 			opt.dialog.AppendResponse(&trace.Response{
 				Body:            err.Error(),
@@ -78,7 +100,7 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 		}
 
 		if opt.logger != nil {
-			opt.logger.Warn("doHTTP got err", zap.Error(err))
+			opt.logger.Warn("doHTTP got err", logFields(opt, zap.Error(err))...)
 		}
 		return nil, _StatusDoReqErr, err
 	}
@@ -87,6 +109,9 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
 		err = errors.Wrapf(err, "read resp body from [%s %s] err", method, url)
+		if opt.fixtures != nil {
+			recordHTTP(method, opt.fixtureURL(url), payload, resp, nil, time.Since(ts), err, opt)
+		}
 		if opt.dialog != nil {
 			opt.dialog.AppendResponse(&trace.Response{
 				Body:            err.Error(),
@@ -95,11 +120,15 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 		}
 
 		if opt.logger != nil {
-			opt.logger.Warn("doHTTP got err", zap.Error(err))
+			opt.logger.Warn("doHTTP got err", logFields(opt, zap.Error(err))...)
 		}
 		return nil, _StatusReadRespErr, err
 	}
 
+	if opt.fixtures != nil {
+		recordHTTP(method, opt.fixtureURL(url), payload, resp, body, time.Since(ts), nil, opt)
+	}
+
 	defer func() {
 		if opt.dialog != nil {
 			opt.dialog.AppendResponse(&trace.Response{
@@ -123,6 +152,63 @@ func doHTTP(ctx context.Context, method, url string, payload []byte, opt *option
 	return body, http.StatusOK, nil
 }
 
+func replayHTTP(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
+	fx, err := opt.fixtures.load(ctx, method, url, payload)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	if fx.Error != "" {
+		err = errors.New(fx.Error)
+		if opt.dialog != nil {
+			opt.dialog.AppendResponse(&trace.Response{
+				Body:            err.Error(),
+				CostMillisecond: fx.CostMillisecond,
+			})
+		}
+		return nil, _StatusDoReqErr, err
+	}
+
+	body := []byte(fx.Response.Body)
+	if opt.dialog != nil {
+		opt.dialog.AppendResponse(&trace.Response{
+			Header:          fx.Response.Header,
+			HttpCode:        fx.Response.HttpCode,
+			HttpCodeMsg:     http.StatusText(fx.Response.HttpCode),
+			Body:            fx.Response.Body,
+			CostMillisecond: fx.CostMillisecond,
+		})
+	}
+
+	if fx.Response.HttpCode != http.StatusOK {
+		return nil, fx.Response.HttpCode, newReplyErr(
+			fx.Response.HttpCode,
+			body,
+			errors.Errorf("do [%s %s] return code: %d message: %s", method, url, fx.Response.HttpCode, fx.Response.Body),
+		)
+	}
+
+	return body, http.StatusOK, nil
+}
+
+// logFields 有 trace 时日志带上 trace ID，字段名与 logger.ContextFields 一致
+func logFields(opt *option, fields ...zap.Field) []zap.Field {
+	if opt.trace != nil {
+		fields = append(fields, zap.String(logger.TraceIDKey, opt.trace.ID()))
+	}
+	return fields
+}
+
+func recordHTTP(method, url string, payload []byte, resp *http.Response, body []byte, cost time.Duration, reqErr error, opt *option) {
+	if opt.fixtures.mode != FixtureRecord {
+		return
+	}
+
+	if err := opt.fixtures.record(method, url, payload, opt.header, resp, body, cost, reqErr); err != nil && opt.logger != nil {
+		opt.logger.Warn("record fixture got err", logFields(opt, zap.Error(err))...)
+	}
+}
+
 func withoutBody(method, url string, form httpURL.Values, options ...Option) (httpCode int, body []byte, err error) {
 	if url == "" {
 		err = errors.New("url required")
@@ -143,6 +229,7 @@ func withoutBody(method, url string, form httpURL.Values, options ...Option) (ht
 			opt.dialog.Success = err == nil
 			opt.dialog.CostMillisecond = time.Since(ts).Milliseconds()
 			opt.trace.AppendDialog(opt.dialog)
+			endSpan(opt.span, httpCode, err)
 		}
 
 		releaseOption(opt)
@@ -156,7 +243,8 @@ func withoutBody(method, url string, form httpURL.Values, options ...Option) (ht
 	}
 	opt.header["Content-Type"] = []string{"application/x-www-form-urlencoded; charset=utf-8"}
 	if opt.trace != nil {
-		opt.header[trace.Header] = []string{opt.trace.ID()}
+		opt.span = startSpan(opt.trace, opt.header, method, url)
+		opt.dialog.SpanID = opt.span.SpanID
 	}
 
 	ttl := opt.ttl
@@ -217,7 +305,7 @@ func withoutBody(method, url string, form httpURL.Values, options ...Option) (ht
 		}
 
 		raw, _ := json.MarshalIndent(info, "", " ")
-		opt.logger.Warn(string(raw))
+		opt.logger.Warn(string(raw), logFields(opt)...)
 
 	}()
 
@@ -281,6 +369,7 @@ func withFormBody(method, url string, form httpURL.Values, options ...Option) (h
 			opt.dialog.Success = err == nil
 			opt.dialog.CostMillisecond = time.Since(ts).Milliseconds()
 			opt.trace.AppendDialog(opt.dialog)
+			endSpan(opt.span, httpCode, err)
 		}
 
 		releaseOption(opt)
@@ -293,7 +382,8 @@ func withFormBody(method, url string, form httpURL.Values, options ...Option) (h
 	}
 	opt.header["Content-Type"] = []string{"application/x-www-form-urlencoded; charset=utf-8"}
 	if opt.trace != nil {
-		opt.header[trace.Header] = []string{opt.trace.ID()}
+		opt.span = startSpan(opt.trace, opt.header, method, url)
+		opt.dialog.SpanID = opt.span.SpanID
 	}
 
 	ttl := opt.ttl
@@ -356,7 +446,7 @@ func withFormBody(method, url string, form httpURL.Values, options ...Option) (h
 		}
 
 		raw, _ := json.MarshalIndent(info, "", " ")
-		opt.logger.Warn(string(raw))
+		opt.logger.Warn(string(raw), logFields(opt)...)
 
 	}()
 
@@ -390,6 +480,7 @@ func withJSONBody(method, url string, raw json.RawMessage, options ...Option) (h
 			opt.dialog.Success = err == nil
 			opt.dialog.CostMillisecond = time.Since(ts).Milliseconds()
 			opt.trace.AppendDialog(opt.dialog)
+			endSpan(opt.span, httpCode, err)
 		}
 
 		releaseOption(opt)
@@ -402,7 +493,8 @@ func withJSONBody(method, url string, raw json.RawMessage, options ...Option) (h
 	}
 	opt.header["Content-Type"] = []string{"application/json; charset=utf-8"}
 	if opt.trace != nil {
-		opt.header[trace.Header] = []string{opt.trace.ID()}
+		opt.span = startSpan(opt.trace, opt.header, method, url)
+		opt.dialog.SpanID = opt.span.SpanID
 	}
 
 	ttl := opt.ttl
@@ -435,7 +527,9 @@ func withJSONBody(method, url string, raw json.RawMessage, options ...Option) (h
 	}
 
 	defer func() {
-
+		if opt.logger == nil {
+			return
+		}
 		info := &struct {
 			TraceID string `json:"trace_id"`
 			Request struct {
@@ -462,7 +556,7 @@ func withJSONBody(method, url string, raw json.RawMessage, options ...Option) (h
 		}
 
 		raw, _ := json.MarshalIndent(info, "", " ")
-		opt.logger.Warn(string(raw))
+		opt.logger.Warn(string(raw), logFields(opt)...)
 
 	}()
 
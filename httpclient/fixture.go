@@ -0,0 +1,169 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/phper95/pkg/errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// FixtureRecord 发起真实请求，并将请求/响应录制到文件
+	FixtureRecord FixtureMode = iota + 1
+	// FixtureReplay 不发起真实请求，从文件中回放录制的响应
+	FixtureReplay
+
+	redactedValue = "******"
+)
+
+// 默认脱敏的 header
+var defaultRedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie", "Proxy-Authorization"}
+
+// FixtureMode 录制或回放
+type FixtureMode int
+
+// Fixtures 按 method、url、body 匹配的请求/响应录制文件集合，用于离线运行集成测试
+type Fixtures struct {
+	mux             sync.Mutex
+	mode            FixtureMode
+	dir             string
+	redactHeaders   map[string]bool
+	simulateLatency bool
+}
+
+// fixture 单次录制的请求/响应
+type fixture struct {
+	Request struct {
+		Method string              `json:"method"`
+		URL    string              `json:"url"`
+		Header map[string][]string `json:"header"`
+		Body   string              `json:"body"`
+	} `json:"request"`
+	Response struct {
+		HttpCode int                 `json:"http_code"`
+		Header   map[string][]string `json:"header"`
+		Body     string              `json:"body"`
+	} `json:"response"`
+	Error           string `json:"error,omitempty"` // 请求未拿到响应时的错误信息
+	CostMillisecond int64  `json:"cost_millisecond"`
+}
+
+// NewFixtureRecorder 创建录制器，redactHeaders 中的 header 会在写入文件前脱敏
+func NewFixtureRecorder(dir string, redactHeaders ...string) *Fixtures {
+	f := &Fixtures{
+		mode:          FixtureRecord,
+		dir:           dir,
+		redactHeaders: make(map[string]bool),
+	}
+	for _, key := range append(defaultRedactHeaders, redactHeaders...) {
+		f.redactHeaders[http.CanonicalHeaderKey(key)] = true
+	}
+	return f
+}
+
+// NewFixtureReplayer 创建回放器，simulateLatency 为 true 时按录制时的耗时延迟返回
+func NewFixtureReplayer(dir string, simulateLatency bool) *Fixtures {
+	return &Fixtures{
+		mode:            FixtureReplay,
+		dir:             dir,
+		simulateLatency: simulateLatency,
+	}
+}
+
+// Mode 录制或回放
+func (f *Fixtures) Mode() FixtureMode {
+	return f.mode
+}
+
+func (f *Fixtures) path(method, url string, payload []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte("\n"))
+	hash.Write([]byte(url))
+	hash.Write([]byte("\n"))
+	hash.Write(payload)
+
+	name := strings.ToLower(method) + "_" + hex.EncodeToString(hash.Sum(nil))[:16] + ".json"
+	return filepath.Join(f.dir, name)
+}
+
+func (f *Fixtures) redact(header map[string][]string) map[string][]string {
+	if len(header) == 0 {
+		return nil
+	}
+
+	redacted := make(map[string][]string, len(header))
+	for key, values := range header {
+		if f.redactHeaders[http.CanonicalHeaderKey(key)] {
+			redacted[key] = []string{redactedValue}
+			continue
+		}
+		redacted[key] = values
+	}
+	return redacted
+}
+
+func (f *Fixtures) record(method, url string, payload []byte, header map[string][]string, resp *http.Response, body []byte, cost time.Duration, reqErr error) error {
+	fx := new(fixture)
+	fx.Request.Method = method
+	fx.Request.URL = url
+	fx.Request.Header = f.redact(header)
+	fx.Request.Body = string(payload)
+	fx.CostMillisecond = cost.Milliseconds()
+	if reqErr != nil {
+		fx.Error = reqErr.Error()
+	}
+	if resp != nil {
+		fx.Response.HttpCode = resp.StatusCode
+		fx.Response.Header = f.redact(resp.Header)
+		fx.Response.Body = string(body)
+	}
+
+	raw, err := json.MarshalIndent(fx, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshal fixture err")
+	}
+
+	f.mux.Lock()
+	defer f.mux.Unlock()
+
+	if err = os.MkdirAll(f.dir, 0755); err != nil {
+		return errors.Wrapf(err, "mkdir fixture dir `%s` err", f.dir)
+	}
+
+	file := f.path(method, url, payload)
+	if err = ioutil.WriteFile(file, raw, 0666); err != nil {
+		return errors.Wrapf(err, "write fixture `%s` err", file)
+	}
+	return nil
+}
+
+func (f *Fixtures) load(ctx context.Context, method, url string, payload []byte) (*fixture, error) {
+	file := f.path(method, url, payload)
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.Wrapf(err, "no fixture recorded for [%s %s]", method, url)
+	}
+
+	fx := new(fixture)
+	if err = json.Unmarshal(raw, fx); err != nil {
+		return nil, errors.Wrapf(err, "unmarshal fixture `%s` err", file)
+	}
+
+	if f.simulateLatency && fx.CostMillisecond > 0 {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrapf(ctx.Err(), "replay [%s %s] err", method, url)
+		case <-time.After(time.Duration(fx.CostMillisecond) * time.Millisecond):
+		}
+	}
+	return fx, nil
+}
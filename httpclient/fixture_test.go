@@ -0,0 +1,87 @@
+package httpclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixtures(t *testing.T) {
+	dir := t.TempDir()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("id") == "0" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not found"))
+			return
+		}
+		w.Write([]byte("ok " + r.URL.Query().Get("id")))
+	}))
+	url := server.URL
+
+	recorder := NewFixtureRecorder(dir)
+	if _, _, err := Get(url, map[string][]string{"id": {"1"}}, WithFixtures(recorder), WithHeader("Authorization", "secret-token")); err != nil {
+		t.Fatal(err)
+	}
+	Get(url, map[string][]string{"id": {"0"}}, WithFixtures(recorder), WithOnFailedRetry(1, 0, nil))
+	server.Close()
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(files) != 2 {
+		t.Fatalf("expect 2 fixtures, got %d", len(files))
+	}
+	for _, file := range files {
+		raw, _ := ioutil.ReadFile(file)
+		if strings.Contains(string(raw), "secret-token") {
+			t.Fatalf("fixture %s not redacted", file)
+		}
+	}
+
+	replayer := NewFixtureReplayer(dir, false)
+	_, body, err := Get(url, map[string][]string{"id": {"1"}}, WithFixtures(replayer))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok 1" {
+		t.Fatalf("unexpected body %q", body)
+	}
+
+	httpCode, _, err := Get(url, map[string][]string{"id": {"0"}}, WithFixtures(replayer))
+	if replyErr, ok := ToReplyErr(err); !ok || httpCode != http.StatusNotFound || string(replyErr.Body()) != "not found" {
+		t.Fatalf("unexpected replay %d %v", httpCode, err)
+	}
+
+	if _, _, err = Get(url, map[string][]string{"id": {"2"}}, WithFixtures(replayer)); err == nil {
+		t.Fatal("expect err for missing fixture")
+	}
+}
+
+func TestFixturesWithBalancer(t *testing.T) {
+	dir := t.TempDir()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("pong"))
+	})
+	a, b := httptest.NewServer(handler), httptest.NewServer(handler)
+	defer a.Close()
+	defer b.Close()
+
+	balancer, err := NewBalancer(StaticResolver{a.URL, b.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer balancer.Close()
+
+	if _, _, err = Get("/ping", nil, WithBalancer(balancer), WithFixtures(NewFixtureRecorder(dir))); err != nil {
+		t.Fatal(err)
+	}
+
+	// 回放时无论选中哪个地址都匹配同一个录制文件
+	replayer := NewFixtureReplayer(dir, false)
+	for i := 0; i < 2; i++ {
+		if _, body, err := Get("/ping", nil, WithBalancer(balancer), WithFixtures(replayer)); err != nil || string(body) != "pong" {
+			t.Fatalf("unexpected replay %q %v", body, err)
+		}
+	}
+}
@@ -1,9 +1,23 @@
 module github.com/phper95/pkg/httpclient
 
-go 1.16
+go 1.18
 
 require (
 	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/logger v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/sign v0.0.0-20230517145757-27be2fc31eea
 	github.com/phper95/pkg/trace v0.0.0-20230517145757-27be2fc31eea
+	github.com/sony/gobreaker v0.4.1
 	go.uber.org/zap v1.21.0
 )
+
+require (
+	github.com/phper95/pkg/timeutil v0.0.0-20230517145757-27be2fc31eea // indirect
+	github.com/pkg/errors v0.9.1 // indirect
+	go.uber.org/atomic v1.7.0 // indirect
+	go.uber.org/multierr v1.6.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.0.0 // indirect
+)
+
+// 已发布的 sign 依赖的 timeutil 版本声明的 module 路径为 gitee.com/phper95/pkg/timeutil，无法使用
+exclude github.com/phper95/pkg/timeutil v0.0.0-20220722023345-0b3333d26940
@@ -0,0 +1,172 @@
+package httpclient
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultLatencySamples LatencyStats 默认保留最近1000次请求的耗时
+	DefaultLatencySamples = 1000
+
+	// 样本数少于该值时，使用 WithHedge 设置的固定延迟
+	minLatencySamples = 20
+)
+
+// LatencyStats 记录最近请求的耗时，用于按分位数计算对冲请求的延迟，并发安全
+type LatencyStats struct {
+	mux     sync.Mutex
+	samples []time.Duration
+	next    int
+	full    bool
+}
+
+// NewLatencyStats 创建 LatencyStats，size 为保留的样本数
+func NewLatencyStats(size int) *LatencyStats {
+	if size <= 0 {
+		size = DefaultLatencySamples
+	}
+	return &LatencyStats{samples: make([]time.Duration, size)}
+}
+
+// Observe 记录一次请求耗时
+func (s *LatencyStats) Observe(cost time.Duration) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.samples[s.next] = cost
+	s.next++
+	if s.next == len(s.samples) {
+		s.next = 0
+		s.full = true
+	}
+}
+
+// Percentile 返回分位数耗时，例如 0.95；样本不足时返回 0
+func (s *LatencyStats) Percentile(p float64) time.Duration {
+	s.mux.Lock()
+	n := s.next
+	if s.full {
+		n = len(s.samples)
+	}
+	if n < minLatencySamples {
+		s.mux.Unlock()
+		return 0
+	}
+	sorted := make([]time.Duration, n)
+	copy(sorted, s.samples[:n])
+	s.mux.Unlock()
+
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	idx := int(float64(n)*p+0.5) - 1
+	if idx < 0 {
+		idx = 0
+	}
+	if idx >= n {
+		idx = n - 1
+	}
+	return sorted[idx]
+}
+
+type attemptResult struct {
+	body     []byte
+	httpCode int
+	err      error
+}
+
+func hedgeable(method string, opt *option) bool {
+	if opt.hedgeDelay <= 0 && opt.hedgeStats == nil {
+		return false
+	}
+	return method == http.MethodGet || method == http.MethodHead
+}
+
+func hedgeDelay(opt *option) time.Duration {
+	if opt.hedgeStats != nil {
+		if p95 := opt.hedgeStats.Percentile(0.95); p95 > 0 {
+			return p95
+		}
+	}
+	return opt.hedgeDelay
+}
+
+// doHedged 先发出一次请求，delay 后仍未返回则再发出一次相同的请求，使用先成功的结果并取消另一次
+func doHedged(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
+	// 返回前先取消未完成的请求并等待其退出，避免其在 option 被回收后继续使用
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, 2)
+	launch := func() {
+		// 每次请求使用独立的 header 和 body，避免两次请求并发修改同一份数据
+		attemptOpt := opt.clone()
+		attemptPayload := append([]byte(nil), payload...)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, httpCode, err := doAttempt(ctx, method, url, attemptPayload, attemptOpt)
+			results <- attemptResult{body: body, httpCode: httpCode, err: err}
+		}()
+	}
+
+	launch()
+	delay := hedgeDelay(opt)
+	if delay <= 0 {
+		r := <-results
+		return r.body, r.httpCode, r.err
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case r := <-results:
+		return r.body, r.httpCode, r.err
+	case <-timer.C:
+		launch()
+	}
+
+	var r attemptResult
+	for i := 0; i < 2; i++ {
+		if r = <-results; r.err == nil {
+			return r.body, r.httpCode, r.err
+		}
+	}
+	return r.body, r.httpCode, r.err
+}
+
+// doAttempt 执行一次请求，WithAttemptTTL 限制的是单次请求的时长，WithTTL 限制的是包含重试在内的总时长
+func doAttempt(ctx context.Context, method, url string, payload []byte, opt *option) ([]byte, int, error) {
+	if opt.attemptTTL > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opt.attemptTTL)
+		defer cancel()
+	}
+
+	ts := time.Now()
+	send := roundTrip
+	if opt.balancer != nil {
+		send = doBalanced
+	}
+
+	body, httpCode, err := send(ctx, method, url, payload, opt)
+	// 凭证过期时刷新后重试一次
+	if httpCode == http.StatusUnauthorized && opt.auth != nil && opt.auth.Refresh(ctx) == nil {
+		body, httpCode, err = send(ctx, method, url, payload, opt)
+	}
+
+	if opt.hedgeStats != nil && err == nil {
+		opt.hedgeStats.Observe(time.Since(ts))
+	}
+	return body, httpCode, err
+}
@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 第一次请求很慢，之后的请求立即返回
+func newSlowFirstServer(slow time.Duration) *httptest.Server {
+	var count int32
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&count, 1) == 1 {
+			select {
+			case <-time.After(slow):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+}
+
+func TestHedge(t *testing.T) {
+	server := newSlowFirstServer(time.Second)
+	defer server.Close()
+
+	ts := time.Now()
+	_, body, err := Get(server.URL, nil, WithHedge(50*time.Millisecond, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" || time.Since(ts) > 500*time.Millisecond {
+		t.Fatalf("unexpected hedged response %q cost %v", body, time.Since(ts))
+	}
+}
+
+func TestHedgeWithBody(t *testing.T) {
+	var count int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		payload, _ := ioutil.ReadAll(r.Body)
+		if string(payload) != "payload" || r.Header.Get("X-Test") != "1" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if atomic.AddInt32(&count, 1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	opt := getOption()
+	defer releaseOption(opt)
+	opt.header["X-Test"] = []string{"1"}
+	opt.hedgeDelay = 20 * time.Millisecond
+
+	body, httpCode, err := doHedged(context.Background(), http.MethodGet, server.URL, []byte("payload"), opt)
+	if err != nil || httpCode != http.StatusOK || string(body) != "ok" {
+		t.Fatalf("unexpected hedged response %d %q %v", httpCode, body, err)
+	}
+	if atomic.LoadInt32(&count) != 2 {
+		t.Fatalf("expect 2 attempts, got %d", count)
+	}
+}
+
+func TestAttemptTTL(t *testing.T) {
+	server := newSlowFirstServer(time.Second)
+	defer server.Close()
+
+	ts := time.Now()
+	_, body, err := Get(server.URL, nil, WithAttemptTTL(50*time.Millisecond), WithOnFailedRetry(2, time.Millisecond, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != "ok" || time.Since(ts) > 500*time.Millisecond {
+		t.Fatalf("unexpected response %q cost %v", body, time.Since(ts))
+	}
+}
+
+func TestLatencyStats(t *testing.T) {
+	stats := NewLatencyStats(100)
+	if stats.Percentile(0.95) != 0 {
+		t.Fatal("expect 0 without enough samples")
+	}
+	for i := 1; i <= 100; i++ {
+		stats.Observe(time.Duration(i) * time.Millisecond)
+	}
+	if p95 := stats.Percentile(0.95); p95 != 95*time.Millisecond {
+		t.Fatalf("unexpected p95 %v", p95)
+	}
+}
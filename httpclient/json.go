@@ -0,0 +1,99 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"github.com/phper95/pkg/errors"
+	httpURL "net/url"
+)
+
+// JSONReplyErr 错误响应，在 ReplyErr 的基础上将返回的 body 解析为调用方指定的类型 E
+type JSONReplyErr[E any] struct {
+	ReplyErr
+	Detail E
+}
+
+// Unwrap 返回原始的 ReplyErr
+func (e *JSONReplyErr[E]) Unwrap() error {
+	return e.ReplyErr
+}
+
+// DoJSON 将 req 编码为 json 发送，并将成功响应的 body 解析为 Resp
+func DoJSON[Req, Resp any](method, url string, req Req, options ...Option) (resp Resp, err error) {
+	return doJSON[Req, Resp](method, url, req, nil, options...)
+}
+
+// DoJSONWithError 同 DoJSON，当响应码不是 200 时，将 body 解析为 E 并通过 *JSONReplyErr[E] 返回
+func DoJSONWithError[Req, Resp, E any](method, url string, req Req, options ...Option) (resp Resp, err error) {
+	return doJSON[Req, Resp](method, url, req, decodeReplyErr[E], options...)
+}
+
+// GetJSON get 请求，并将成功响应的 body 解析为 Resp
+func GetJSON[Resp any](url string, form httpURL.Values, options ...Option) (resp Resp, err error) {
+	_, body, err := Get(url, form, options...)
+	if err != nil {
+		return
+	}
+
+	err = decodeJSON(body, &resp)
+	return
+}
+
+// GetJSONWithError 同 GetJSON，当响应码不是 200 时，将 body 解析为 E 并通过 *JSONReplyErr[E] 返回
+func GetJSONWithError[Resp, E any](url string, form httpURL.Values, options ...Option) (resp Resp, err error) {
+	_, body, err := Get(url, form, options...)
+	if err != nil {
+		err = decodeReplyErr[E](err)
+		return
+	}
+
+	err = decodeJSON(body, &resp)
+	return
+}
+
+func doJSON[Req, Resp any](method, url string, req Req, onErr func(error) error, options ...Option) (resp Resp, err error) {
+	raw, err := json.Marshal(req)
+	if err != nil {
+		err = errors.Wrapf(err, "marshal request [%s %s] err", method, url)
+		return
+	}
+
+	_, body, err := withJSONBody(method, url, raw, options...)
+	if err != nil {
+		if onErr != nil {
+			err = onErr(err)
+		}
+		return
+	}
+
+	err = decodeJSON(body, &resp)
+	return
+}
+
+func decodeJSON(body []byte, v interface{}) error {
+	if len(body) == 0 {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return errors.Wrap(err, "unmarshal response body err")
+	}
+	return nil
+}
+
+func decodeReplyErr[E any](err error) error {
+	replyErr, ok := ToReplyErr(err)
+	if !ok {
+		return err
+	}
+
+	jsonErr := &JSONReplyErr[E]{ReplyErr: replyErr}
+	if len(replyErr.Body()) == 0 {
+		return jsonErr
+	}
+
+	if e := json.Unmarshal(replyErr.Body(), &jsonErr.Detail); e != nil {
+		// body 不是约定的错误格式时，保留原始错误
+		return err
+	}
+	return jsonErr
+}
@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type echoReq struct {
+	Name string `json:"name"`
+}
+
+type echoResp struct {
+	Greeting string `json:"greeting"`
+}
+
+type apiErr struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func TestDoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := new(echoReq)
+		if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+			t.Error(err)
+			return
+		}
+		if req.Name == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":10001,"message":"name required"}`))
+			return
+		}
+		w.Write([]byte(`{"greeting":"hello ` + req.Name + `"}`))
+	}))
+	defer server.Close()
+
+	resp, err := DoJSON[echoReq, echoResp](http.MethodPost, server.URL, echoReq{Name: "pkg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Greeting != "hello pkg" {
+		t.Fatalf("unexpected greeting %q", resp.Greeting)
+	}
+
+	_, err = DoJSONWithError[echoReq, echoResp, apiErr](http.MethodPost, server.URL, echoReq{})
+	replyErr, ok := err.(*JSONReplyErr[apiErr])
+	if !ok {
+		t.Fatalf("expect *JSONReplyErr, got %T", err)
+	}
+	if replyErr.StatusCode() != http.StatusBadRequest || replyErr.Detail.Code != 10001 {
+		t.Fatalf("unexpected reply err %d %+v", replyErr.StatusCode(), replyErr.Detail)
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"greeting":"hello ` + r.URL.Query().Get("name") + `"}`))
+	}))
+	defer server.Close()
+
+	resp, err := GetJSON[echoResp](server.URL, map[string][]string{"name": {"pkg"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Greeting != "hello pkg" {
+		t.Fatalf("unexpected greeting %q", resp.Greeting)
+	}
+}
@@ -28,11 +28,21 @@ type option struct {
 	header      map[string][]string
 	trace       *trace.Trace
 	dialog      *trace.Dialog
+	span        *trace.Span
 	logger      *zap.Logger
 	retryTimes  int
 	retryDelay  time.Duration
 	retryVerify RetryVerify
 	mock        Mock
+	fixtures    *Fixtures
+	balancer    *Balancer
+	hashKey     string
+	attemptTTL  time.Duration
+	hedgeDelay  time.Duration
+	hedgeStats  *LatencyStats
+	auth        AuthProvider
+	// rawURL 调用方传入的 url，使用 Balancer 时实际请求的地址会被改写，录制回放按 rawURL 匹配
+	rawURL string
 }
 
 func (o *option) reset() {
@@ -40,11 +50,38 @@ func (o *option) reset() {
 	o.header = make(map[string][]string)
 	o.trace = nil
 	o.dialog = nil
+	o.span = nil
 	o.logger = nil
 	o.retryTimes = 0
 	o.retryDelay = 0
 	o.retryVerify = nil
 	o.mock = nil
+	o.fixtures = nil
+	o.balancer = nil
+	o.hashKey = ""
+	o.attemptTTL = 0
+	o.hedgeDelay = 0
+	o.hedgeStats = nil
+	o.auth = nil
+	o.rawURL = ""
+}
+
+// fixtureURL 录制回放使用的 url，不受 Balancer 选择的地址影响
+func (o *option) fixtureURL(url string) string {
+	if o.rawURL != "" {
+		return o.rawURL
+	}
+	return url
+}
+
+// clone 复制 option，header 使用新的 map，用于并发的对冲请求互不影响
+func (o *option) clone() *option {
+	c := *o
+	c.header = make(map[string][]string, len(o.header))
+	for key, value := range o.header {
+		c.header[key] = append([]string(nil), value...)
+	}
+	return &c
 }
 
 func getOption() *option {
@@ -56,7 +93,7 @@ func releaseOption(opt *option) {
 	cache.Put(opt)
 }
 
-// WithTTL 本次http请求最长执行时间
+// WithTTL 本次http请求最长执行时间，包含所有重试和对冲请求
 func WithTTL(ttl time.Duration) Option {
 	return func(opt *option) {
 		opt.ttl = ttl
@@ -88,6 +125,8 @@ func WithLogger(logger *zap.Logger) Option {
 }
 
 // WithMock 设置 mock 数据
+//
+// Deprecated: mock 忽略请求且只能返回 200，请使用 WithFixtures 回放录制的请求
 func WithMock(m Mock) Option {
 	return func(opt *option) {
 		opt.mock = m
@@ -102,3 +141,48 @@ func WithOnFailedRetry(retryTimes int, retryDelay time.Duration, retryVerify Ret
 		opt.retryVerify = retryVerify
 	}
 }
+
+// WithFixtures 录制或回放请求，见 NewFixtureRecorder 和 NewFixtureReplayer
+func WithFixtures(f *Fixtures) Option {
+	return func(opt *option) {
+		opt.fixtures = f
+	}
+}
+
+// WithBalancer 通过负载均衡选择地址，请求 url 的 scheme 和 host 会被替换为选中的地址，
+// 此时 url 可以只传 path，例如 /api/user?id=1
+func WithBalancer(b *Balancer) Option {
+	return func(opt *option) {
+		opt.balancer = b
+	}
+}
+
+// WithHashKey 设置 ConsistentHash 策略使用的 key，默认使用请求 url
+func WithHashKey(key string) Option {
+	return func(opt *option) {
+		opt.hashKey = key
+	}
+}
+
+// WithAttemptTTL 单次请求最长执行时间，超时后按 WithOnFailedRetry 重试，总时长仍受 WithTTL 限制
+func WithAttemptTTL(ttl time.Duration) Option {
+	return func(opt *option) {
+		opt.attemptTTL = ttl
+	}
+}
+
+// WithHedge 对幂等的 GET/HEAD 请求，delay 后仍未返回时再发出一次相同的请求，使用先成功的结果并取消另一次；
+// stats 不为 nil 时记录请求耗时，并在样本足够后使用 p95 耗时作为 delay
+func WithHedge(delay time.Duration, stats *LatencyStats) Option {
+	return func(opt *option) {
+		opt.hedgeDelay = delay
+		opt.hedgeStats = stats
+	}
+}
+
+// WithAuth 设置认证方式，请求返回 401 时会刷新凭证并重试一次
+func WithAuth(auth AuthProvider) Option {
+	return func(opt *option) {
+		opt.auth = auth
+	}
+}
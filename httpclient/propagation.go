@@ -0,0 +1,46 @@
+package httpclient
+
+import (
+	"github.com/phper95/pkg/trace"
+	"strings"
+)
+
+// headerCarrier 将链路信息写入请求 header，保留 key 的原始大小写
+type headerCarrier map[string][]string
+
+func (h headerCarrier) Get(key string) string {
+	if v := h[key]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h[key] = []string{value}
+}
+
+// startSpan 开始本次请求的 span，并通过 TRACE-ID、traceparent、tracestate header 传递给下游服务
+func startSpan(t *trace.Trace, header map[string][]string, method, url string) *trace.Span {
+	// query 中可能有敏感信息，只记录 ? 之前的部分
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		url = url[:i]
+	}
+
+	span := t.StartSpan("HTTP "+method, nil)
+	span.SetAttribute("http.method", method)
+	span.SetAttribute("http.url", url)
+
+	trace.Inject(headerCarrier(header), t, span)
+	return span
+}
+
+// endSpan 记录响应状态码和错误并结束 span
+func endSpan(span *trace.Span, httpCode int, err error) {
+	if span == nil {
+		return
+	}
+
+	span.SetAttribute("http.status_code", httpCode)
+	span.SetError(err)
+	span.End()
+}
@@ -0,0 +1,34 @@
+package httpclient
+
+import (
+	"github.com/phper95/pkg/trace"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestTracePropagation(t *testing.T) {
+	var downstream *trace.Trace
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		downstream = trace.Extract(r.Header)
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	tr := trace.New("")
+	tr.TraceState = "vendor=abc"
+	if _, _, err := Get(server.URL+"?token=secret", nil, WithTrace(tr)); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tr.Spans) != 1 {
+		t.Fatalf("expect 1 span, got %d", len(tr.Spans))
+	}
+	span := tr.Spans[0]
+	if downstream.ID() != tr.ID() || downstream.ParentSpanID != span.SpanID || downstream.TraceState != "vendor=abc" {
+		t.Fatalf("unexpected downstream trace %+v", downstream)
+	}
+	if span.ParentSpanID != tr.SpanID || span.EndTime.IsZero() || span.Attributes["http.status_code"] != http.StatusOK || span.Attributes["http.url"] != server.URL {
+		t.Fatalf("unexpected span %+v", span)
+	}
+}
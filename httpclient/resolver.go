@@ -0,0 +1,112 @@
+package httpclient
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/phper95/pkg/errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Resolver 服务发现，返回服务当前可用的地址列表，例如 http://10.0.0.1:8080
+type Resolver interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// StaticResolver 固定的地址列表
+type StaticResolver []string
+
+// Resolve 返回固定的地址列表
+func (r StaticResolver) Resolve(ctx context.Context) ([]string, error) {
+	if len(r) == 0 {
+		return nil, errors.New("static endpoints required")
+	}
+	return r, nil
+}
+
+type dnsSRVResolver struct {
+	scheme  string
+	service string
+	proto   string
+	name    string
+}
+
+// NewDNSSRVResolver 通过 DNS SRV 记录发现服务，查询 _service._proto.name，
+// scheme 为访问服务使用的协议，例如 http
+func NewDNSSRVResolver(scheme, service, proto, name string) Resolver {
+	return &dnsSRVResolver{
+		scheme:  scheme,
+		service: service,
+		proto:   proto,
+		name:    name,
+	}
+}
+
+// Resolve 查询 SRV 记录
+func (r *dnsSRVResolver) Resolve(ctx context.Context) ([]string, error) {
+	_, records, err := net.DefaultResolver.LookupSRV(ctx, r.service, r.proto, r.name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "lookup srv [%s %s %s] err", r.service, r.proto, r.name)
+	}
+
+	endpoints := make([]string, 0, len(records))
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		endpoints = append(endpoints, fmt.Sprintf("%s://%s", r.scheme, net.JoinHostPort(host, strconv.Itoa(int(record.Port)))))
+	}
+	return endpoints, nil
+}
+
+type fileResolver struct {
+	mux       sync.Mutex
+	path      string
+	modTime   time.Time
+	endpoints []string
+}
+
+// NewFileResolver 从文件中读取地址列表，每行一个地址，# 开头的行为注释；
+// 文件修改后，下一次 Resolve 会重新加载
+func NewFileResolver(path string) Resolver {
+	return &fileResolver{path: path}
+}
+
+// Resolve 文件修改时间变化时重新读取文件
+func (r *fileResolver) Resolve(ctx context.Context) ([]string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	info, err := os.Stat(r.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "stat endpoints file `%s` err", r.path)
+	}
+
+	if r.endpoints != nil && info.ModTime().Equal(r.modTime) {
+		return r.endpoints, nil
+	}
+
+	raw, err := ioutil.ReadFile(r.path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "read endpoints file `%s` err", r.path)
+	}
+
+	endpoints := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(raw))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		endpoints = append(endpoints, line)
+	}
+
+	r.modTime = info.ModTime()
+	r.endpoints = endpoints
+	return endpoints, nil
+}
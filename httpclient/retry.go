@@ -15,6 +15,8 @@ const (
 	_StatusReadRespErr = -204
 	// _StatusDoReqErr do req err, should re-call doHTTP again.
 	_StatusDoReqErr = -500
+	// _StatusNoEndpointErr all endpoints of the balancer are ejected.
+	_StatusNoEndpointErr = -503
 )
 
 // RetryVerify Verify parse the body and verify that it is correct
@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"context"
+	"github.com/phper95/pkg/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"sync/atomic"
+)
+
+const (
+	// TraceIDKey 日志中 trace ID 的字段名
+	TraceIDKey = "trace_id"
+	// RequestIDKey 日志中 request ID 的字段名
+	RequestIDKey = "request_id"
+	// UserIDKey 日志中用户 ID 的字段名
+	UserIDKey = "user_id"
+
+	// HeaderRequestID 请求 ID 的 http header
+	HeaderRequestID = "X-Request-Id"
+)
+
+type contextKey struct{}
+
+// traceIDExtractor 从 context 中获取 trace ID，没有注册时使用 trace.IDFromContext
+var traceIDExtractor atomic.Value
+
+// RegisterTraceIDExtractor 注册从 context 中获取 trace ID 的方法，用于替换默认的 trace.IDFromContext，
+// 如使用其它链路追踪组件时
+func RegisterTraceIDExtractor(f func(ctx context.Context) string) {
+	traceIDExtractor.Store(f)
+}
+
+func traceIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if f, ok := traceIDExtractor.Load().(func(ctx context.Context) string); ok && f != nil {
+		return f(ctx)
+	}
+	return trace.IDFromContext(ctx)
+}
+
+// contextFields 存放在 context 中的日志字段
+type contextFields struct {
+	traceID string
+	fields  []zap.Field
+}
+
+func fieldsFromContext(ctx context.Context) *contextFields {
+	if ctx == nil {
+		return nil
+	}
+	cf, _ := ctx.Value(contextKey{}).(*contextFields)
+	return cf
+}
+
+// WithContext 将日志字段存入 context，之后通过 FromContext 或 InfoCtx 等方法打印的日志都会带上这些字段
+func WithContext(ctx context.Context, fields ...zap.Field) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cf := &contextFields{}
+	if parent := fieldsFromContext(ctx); parent != nil {
+		cf.traceID = parent.traceID
+		cf.fields = make([]zap.Field, 0, len(parent.fields)+len(fields))
+		cf.fields = append(cf.fields, parent.fields...)
+	}
+
+	for _, field := range fields {
+		if field.Key == TraceIDKey && field.Type == zapcore.StringType {
+			cf.traceID = field.String
+			continue
+		}
+		cf.fields = append(cf.fields, field)
+	}
+	return context.WithValue(ctx, contextKey{}, cf)
+}
+
+// WithTraceID 将 trace ID 存入 context，未设置时使用 RegisterTraceIDExtractor 注册的方法获取
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return WithContext(ctx, zap.String(TraceIDKey, traceID))
+}
+
+// WithRequestID 将 request ID 存入 context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return WithContext(ctx, zap.String(RequestIDKey, requestID))
+}
+
+// WithUserID 将用户 ID 存入 context
+func WithUserID(ctx context.Context, userID interface{}) context.Context {
+	return WithContext(ctx, zap.Any(UserIDKey, userID))
+}
+
+// ContextFromRequest 从请求的 trace.Header 和 X-Request-Id header 中读取 trace ID 和 request ID 存入 context
+func ContextFromRequest(r *http.Request) context.Context {
+	ctx := r.Context()
+	if traceID := r.Header.Get(trace.Header); traceID != "" {
+		ctx = WithTraceID(ctx, traceID)
+	}
+	if requestID := r.Header.Get(HeaderRequestID); requestID != "" {
+		ctx = WithRequestID(ctx, requestID)
+	}
+	return ctx
+}
+
+// ContextFields 返回 context 中的日志字段，包括 trace ID、request ID、用户等
+func ContextFields(ctx context.Context) []zap.Field {
+	cf := fieldsFromContext(ctx)
+
+	traceID := ""
+	if cf != nil {
+		traceID = cf.traceID
+	}
+	if traceID == "" {
+		traceID = traceIDFromContext(ctx)
+	}
+
+	capacity := 1
+	if cf != nil {
+		capacity += len(cf.fields)
+	}
+	fields := make([]zap.Field, 0, capacity)
+	if traceID != "" {
+		fields = append(fields, zap.String(TraceIDKey, traceID))
+	}
+	if cf != nil {
+		fields = append(fields, cf.fields...)
+	}
+	return fields
+}
+
+// FromContext 返回带有 context 中日志字段的 Logger
+func FromContext(ctx context.Context) *zap.Logger {
+	setLogger()
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return Logger
+	}
+	return Logger.With(fields...)
+}
+
+// ModuleFromContext 返回带有 context 中日志字段的模块 logger，见 Module
+func ModuleFromContext(ctx context.Context, module string) *zap.Logger {
+	l := Module(module)
+	fields := ContextFields(ctx)
+	if len(fields) == 0 {
+		return l
+	}
+	return l.With(fields...)
+}
+
+func InfoCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).WithOptions(zap.AddCallerSkip(1)).Info(msg, fields...)
+}
+func DebugCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).WithOptions(zap.AddCallerSkip(1)).Debug(msg, fields...)
+}
+func WarnCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).WithOptions(zap.AddCallerSkip(1)).Warn(msg, fields...)
+}
+func ErrorCtx(ctx context.Context, msg string, fields ...zap.Field) {
+	FromContext(ctx).WithOptions(zap.AddCallerSkip(1)).Error(msg, fields...)
+}
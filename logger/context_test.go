@@ -0,0 +1,73 @@
+package logger
+
+import (
+	"context"
+	"github.com/phper95/pkg/trace"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestContextLogger(t *testing.T) {
+	logs := newObservedLogger()
+
+	type traceKey struct{}
+	RegisterTraceIDExtractor(func(ctx context.Context) string {
+		id, _ := ctx.Value(traceKey{}).(string)
+		return id
+	})
+	defer RegisterTraceIDExtractor(nil)
+
+	ctx := context.WithValue(context.Background(), traceKey{}, "trace-1")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithUserID(ctx, 100)
+	InfoCtx(ctx, "with trace")
+
+	ctx = WithTraceID(ctx, "trace-2")
+	ErrorCtx(ctx, "with trace id")
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("expect 2 logs, got %d", len(entries))
+	}
+	for i, traceID := range []string{"trace-1", "trace-2"} {
+		fields := entries[i].ContextMap()
+		if fields[TraceIDKey] != traceID || fields[RequestIDKey] != "req-1" || fields[UserIDKey] != int64(100) {
+			t.Fatalf("unexpected fields %v", fields)
+		}
+	}
+}
+
+func TestModuleFromContext(t *testing.T) {
+	logs := newObservedLogger()
+
+	ModuleFromContext(WithTraceID(context.Background(), "trace-1"), "db").Info("query")
+	ModuleFromContext(context.Background(), "db").Info("no context")
+
+	entries := logs.All()
+	if len(entries) != 2 || entries[0].LoggerName != "db" || entries[0].ContextMap()[TraceIDKey] != "trace-1" {
+		t.Fatalf("unexpected logs %v", entries)
+	}
+	if _, ok := entries[1].ContextMap()[TraceIDKey]; ok {
+		t.Fatalf("unexpected fields %v", entries[1].ContextMap())
+	}
+}
+
+func TestContextFromRequest(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(trace.Header, "trace-1")
+	r.Header.Set(HeaderRequestID, "req-1")
+
+	fields := ContextFields(ContextFromRequest(r))
+	if len(fields) != 2 || fields[0].String != "trace-1" || fields[1].String != "req-1" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
+
+func TestContextFieldsFromTrace(t *testing.T) {
+	ctx := trace.NewContext(context.Background(), trace.New("trace-3"))
+
+	fields := ContextFields(ctx)
+	if len(fields) != 1 || fields[0].Key != TraceIDKey || fields[0].String != "trace-3" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
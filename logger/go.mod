@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	github.com/BurntSushi/toml v1.0.0 // indirect
+	github.com/phper95/pkg/trace v0.0.0-20230517145757-27be2fc31eea
 	github.com/pkg/errors v0.9.1
 	go.uber.org/zap v1.21.0
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
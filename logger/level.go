@@ -0,0 +1,220 @@
+package logger
+
+import (
+	"encoding/json"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+)
+
+var (
+	// atomicLevel 全局日志级别，可在运行时修改
+	atomicLevel = zap.NewAtomicLevelAt(DefaultLevel)
+
+	modulesMux sync.RWMutex
+	// moduleLevels 模块单独设置的日志级别，未设置的模块使用全局级别
+	moduleLevels = make(map[string]zap.AtomicLevel)
+	// moduleLoggers 缓存 Module 返回的 logger，InitLogger 后重建
+	moduleLoggers = make(map[string]*zap.Logger)
+)
+
+// AtomicLevel 返回全局日志级别，修改后立即对所有 logger 生效
+func AtomicLevel() zap.AtomicLevel {
+	return atomicLevel
+}
+
+// SetLevel 修改全局日志级别
+func SetLevel(lvl zapcore.Level) {
+	atomicLevel.SetLevel(lvl)
+}
+
+// SetModuleLevel 单独设置模块的日志级别，例如只打开 mq 模块的 debug 日志
+func SetModuleLevel(module string, lvl zapcore.Level) {
+	moduleLevel(module, true).SetLevel(lvl)
+}
+
+// ResetModuleLevel 取消模块单独设置的日志级别，恢复使用全局级别
+func ResetModuleLevel(module string) {
+	modulesMux.Lock()
+	defer modulesMux.Unlock()
+
+	delete(moduleLevels, module)
+}
+
+func moduleLevel(module string, create bool) *zap.AtomicLevel {
+	modulesMux.RLock()
+	lvl, ok := moduleLevels[module]
+	modulesMux.RUnlock()
+	if ok || !create {
+		if !ok {
+			return nil
+		}
+		return &lvl
+	}
+
+	modulesMux.Lock()
+	defer modulesMux.Unlock()
+
+	if lvl, ok = moduleLevels[module]; !ok {
+		lvl = zap.NewAtomicLevelAt(atomicLevel.Level())
+		moduleLevels[module] = lvl
+	}
+	return &lvl
+}
+
+func levelEnabled(module string, lvl zapcore.Level) bool {
+	if module != "" {
+		if moduleLvl := moduleLevel(module, false); moduleLvl != nil {
+			return moduleLvl.Enabled(lvl)
+		}
+	}
+	return atomicLevel.Enabled(lvl)
+}
+
+// levelCore 按全局或模块的日志级别过滤日志
+type levelCore struct {
+	zapcore.Core
+	module string
+}
+
+func newLevelCore(core zapcore.Core, module string) zapcore.Core {
+	if c, ok := core.(*levelCore); ok {
+		core = c.Core
+	}
+	return &levelCore{Core: core, module: module}
+}
+
+func (c *levelCore) Enabled(lvl zapcore.Level) bool {
+	return levelEnabled(c.module, lvl)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), module: c.module}
+}
+
+func (c *levelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// Module 返回模块的 logger，日志中带有模块名，日志级别可通过 SetModuleLevel 单独设置
+func Module(module string) *zap.Logger {
+	setLogger()
+
+	modulesMux.RLock()
+	l, ok := moduleLoggers[module]
+	modulesMux.RUnlock()
+	if ok {
+		return l
+	}
+
+	l = Logger.Named(module).WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return newLevelCore(core, module)
+	}))
+
+	modulesMux.Lock()
+	moduleLoggers[module] = l
+	modulesMux.Unlock()
+	return l
+}
+
+func resetModuleLoggers() {
+	modulesMux.Lock()
+	defer modulesMux.Unlock()
+
+	moduleLoggers = make(map[string]*zap.Logger)
+}
+
+// LevelHandler 查看和修改日志级别的 http handler：
+// GET 返回全局和各模块的日志级别；
+// PUT {"level":"debug"} 修改全局级别，PUT {"level":"debug","module":"mq"} 修改模块级别，
+// PUT {"module":"mq"} 取消模块单独设置的级别
+func LevelHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		type payload struct {
+			Level   *zapcore.Level           `json:"level,omitempty"`
+			Module  string                   `json:"module,omitempty"`
+			Modules map[string]zapcore.Level `json:"modules,omitempty"`
+		}
+
+		enc := json.NewEncoder(w)
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			req := new(payload)
+			if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				enc.Encode(map[string]string{"error": "request body must be json: " + err.Error()})
+				return
+			}
+
+			switch {
+			case req.Module != "" && req.Level != nil:
+				SetModuleLevel(req.Module, *req.Level)
+			case req.Module != "":
+				ResetModuleLevel(req.Module)
+			case req.Level != nil:
+				SetLevel(*req.Level)
+			default:
+				w.WriteHeader(http.StatusBadRequest)
+				enc.Encode(map[string]string{"error": "level or module required"})
+				return
+			}
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			enc.Encode(map[string]string{"error": "only GET and PUT are supported"})
+			return
+		}
+
+		global := atomicLevel.Level()
+		resp := &payload{Level: &global, Modules: make(map[string]zapcore.Level)}
+		modulesMux.RLock()
+		for module, lvl := range moduleLevels {
+			resp.Modules[module] = lvl.Level()
+		}
+		modulesMux.RUnlock()
+		enc.Encode(resp)
+	})
+}
+
+// ToggleDebugOnSignal 收到信号时在 debug 级别和原级别之间切换全局日志级别，
+// 例如 ToggleDebugOnSignal(syscall.SIGUSR1)；返回的函数用于停止监听
+func ToggleDebugOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	done := make(chan struct{})
+
+	go func() {
+		previous := atomicLevel.Level()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ch:
+				if atomicLevel.Level() != zapcore.DebugLevel {
+					previous = atomicLevel.Level()
+					SetLevel(zapcore.DebugLevel)
+				} else {
+					SetLevel(previous)
+				}
+				setLogger()
+				Logger.Warn("log level changed by signal", zap.Stringer("level", atomicLevel.Level()))
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newObservedLogger() *observer.ObservedLogs {
+	core, logs := observer.New(zapcore.DebugLevel)
+	Logger = zap.New(newLevelCore(core, ""))
+	resetModuleLoggers()
+	return logs
+}
+
+func TestModuleLevel(t *testing.T) {
+	logs := newObservedLogger()
+	SetLevel(zapcore.InfoLevel)
+	defer ResetModuleLevel("mq")
+
+	Debug("global debug")
+	Module("mq").Debug("mq debug")
+	if logs.Len() != 0 {
+		t.Fatalf("debug should be disabled, got %d logs", logs.Len())
+	}
+
+	SetModuleLevel("mq", zapcore.DebugLevel)
+	Debug("global debug")
+	Module("mq").Debug("mq debug")
+	Module("es").Debug("es debug")
+	if logs.Len() != 1 || logs.All()[0].LoggerName != "mq" {
+		t.Fatalf("only mq debug should be logged, got %v", logs.All())
+	}
+
+	ResetModuleLevel("mq")
+	SetLevel(zapcore.DebugLevel)
+	defer SetLevel(zapcore.InfoLevel)
+	Module("es").Debug("es debug")
+	if logs.Len() != 2 {
+		t.Fatalf("global debug should be enabled, got %d logs", logs.Len())
+	}
+}
+
+func TestLevelHandler(t *testing.T) {
+	newObservedLogger()
+	defer SetLevel(zapcore.InfoLevel)
+	defer ResetModuleLevel("mq")
+
+	handler := LevelHandler()
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"debug","module":"mq"}`)))
+	if w.Code != http.StatusOK || !levelEnabled("mq", zapcore.DebugLevel) || levelEnabled("", zapcore.DebugLevel) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/log/level", strings.NewReader(`{"level":"error"}`)))
+	if w.Code != http.StatusOK || levelEnabled("", zapcore.WarnLevel) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/log/level", nil))
+	if !strings.Contains(w.Body.String(), `"level":"error"`) || !strings.Contains(w.Body.String(), `"mq":"debug"`) {
+		t.Fatalf("unexpected response %s", w.Body.String())
+	}
+}
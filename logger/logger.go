@@ -1,12 +1,15 @@
 package logger
 
 import (
+	"errors"
+	"fmt"
+	"github.com/phper95/pkg/logger/redact"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
-	"gopkg.in/natefinch/lumberjack.v2"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 )
 
@@ -27,6 +30,16 @@ type option struct {
 	file           io.Writer
 	timeLayout     string
 	disableConsole bool
+
+	samplingFirst      int
+	samplingThereafter int
+	rateLimit          int
+	rateLimitInterval  time.Duration
+	rateLimitKeyFields []string
+	dedupWindow        time.Duration
+	redactor           *redact.Redactor
+	sinks              []*Sink
+	levelFiles         []levelFile
 }
 
 var Logger *zap.Logger
@@ -83,22 +96,13 @@ func WithFileP(file string) Option {
 	}
 }
 
-// WithFileRotationP write log to some file with rotation
-func WithFileRotationP(file string) Option {
-	dir := filepath.Dir(file)
-	if err := os.MkdirAll(dir, 0766); err != nil {
-		panic(err)
-	}
+// WithFileRotationP write log to some file with rotation,
+// 默认单个文件 128M、保留 300 个备份、30 天、压缩备份，可通过 RotationOption 修改
+func WithFileRotationP(file string, options ...RotationOption) Option {
+	writer := newRotateWriter(file, options...)
 
 	return func(opt *option) {
-		opt.file = &lumberjack.Logger{ // concurrent-safed
-			Filename:   file, // 文件路径
-			MaxSize:    128,  // 单个文件最大尺寸，默认单位 M
-			MaxBackups: 300,  // 最多保留 300 个备份
-			MaxAge:     30,   // 最大时间，默认单位 day
-			LocalTime:  true, // 使用本地时间
-			Compress:   true, // 是否压缩 disabled by default
-		}
+		opt.file = writer
 	}
 }
 
@@ -118,13 +122,81 @@ func WithDisableConsole() Option {
 
 // InitLogger
 func InitLogger(opts ...Option) *zap.Logger {
+	opt := newOption(opts)
+
+	// 日志级别由 levelCore 统一过滤，支持运行时修改
+	atomicLevel.SetLevel(opt.level)
+
+	core, stderr := newCore(opt)
+	Logger = zap.New(newLevelCore(core, ""),
+		zap.AddCaller(),
+		zap.ErrorOutput(stderr),
+	)
+
+	Logger = withFields(Logger, opt.fields)
+	resetModuleLoggers()
+	return Logger
+}
+
+// NewJSONLogger 创建 json 格式输出的独立 Logger，不会修改全局 Logger，
+// 日志级别由 opts 决定，不受 SetLevel、SetModuleLevel 影响
+func NewJSONLogger(opts ...Option) (*zap.Logger, error) {
+	opt := newOption(append(opts, WithDisableConsole()))
+	if err := opt.validate(); err != nil {
+		return nil, err
+	}
+
+	core, stderr := newCore(opt)
+	core, err := zapcore.NewIncreaseLevelCore(core, opt.level)
+	if err != nil {
+		return nil, err
+	}
+	return withFields(zap.New(core, zap.AddCaller(), zap.ErrorOutput(stderr)), opt.fields), nil
+}
+
+func newOption(opts []Option) *option {
 	opt := &option{level: DefaultLevel, fields: make(map[string]string)}
 	for _, f := range opts {
 		if f != nil {
 			f(opt)
 		}
 	}
+	return opt
+}
 
+// validate 检查配置是否合法
+func (opt *option) validate() error {
+	if opt.level < zapcore.DebugLevel || opt.level > zapcore.FatalLevel {
+		return fmt.Errorf("invalid log level: %d", opt.level)
+	}
+	if opt.samplingFirst < 0 || opt.samplingThereafter < 0 {
+		return errors.New("sampling must not be negative")
+	}
+	if opt.rateLimit < 0 || opt.rateLimitInterval < 0 || opt.dedupWindow < 0 {
+		return errors.New("rate limit and dedup window must not be negative")
+	}
+	for key := range opt.fields {
+		if key == "" {
+			return errors.New("empty field key")
+		}
+	}
+	for _, sink := range opt.sinks {
+		if sink == nil {
+			return errors.New("nil sink")
+		}
+	}
+	return nil
+}
+
+func withFields(l *zap.Logger, fields map[string]string) *zap.Logger {
+	for key, value := range fields {
+		l = l.WithOptions(zap.Fields(zapcore.Field{Key: key, Type: zapcore.StringType, String: value}))
+	}
+	return l
+}
+
+// newCore 按配置创建输出到 console、文件和 sink 的 core，不做级别过滤
+func newCore(opt *option) (zapcore.Core, zapcore.WriteSyncer) {
 	timeLayout := DefaultTimeLayout
 	if opt.timeLayout != "" {
 		timeLayout = opt.timeLayout
@@ -149,12 +221,12 @@ func InitLogger(opts ...Option) *zap.Logger {
 
 	// lowPriority usd by info\debug\warn
 	lowPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= opt.level && lvl < zapcore.ErrorLevel
+		return lvl < zapcore.ErrorLevel
 	})
 
 	// highPriority usd by error\panic\fatal
 	highPriority := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return lvl >= opt.level && lvl >= zapcore.ErrorLevel
+		return lvl >= zapcore.ErrorLevel
 	})
 
 	stdout := zapcore.Lock(os.Stdout) // lock for concurrent safe
@@ -181,21 +253,21 @@ func InitLogger(opts ...Option) *zap.Logger {
 			zapcore.NewCore(encoder,
 				zapcore.AddSync(opt.file),
 				zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-					return lvl >= opt.level
+					return true
 				}),
 			),
 		)
 	}
 
-	Logger = zap.New(core,
-		zap.AddCaller(),
-		zap.ErrorOutput(stderr),
-	)
+	if len(opt.levelFiles) > 0 {
+		core = zapcore.NewTee(append([]zapcore.Core{core}, levelFileCores(encoder, opt.levelFiles)...)...)
+	}
 
-	for key, value := range opt.fields {
-		Logger = Logger.WithOptions(zap.Fields(zapcore.Field{Key: key, Type: zapcore.StringType, String: value}))
+	for _, sink := range opt.sinks {
+		core = zapcore.NewTee(core, newSinkCore(zapcore.NewJSONEncoder(encoderConfig), sink))
 	}
-	return Logger
+
+	return wrapSampling(NewRedactCore(core, opt.redactor), opt), stderr
 }
 
 func GetLogger() *zap.Logger {
@@ -203,7 +275,13 @@ func GetLogger() *zap.Logger {
 }
 func setLogger() {
 	if Logger == nil {
-		Logger, _ = zap.NewProduction(zap.AddStacktrace(zapcore.LevelEnabler(zapcore.ErrorLevel)))
+		cfg := zap.NewProductionConfig()
+		// 默认 core 输出所有级别，由 levelCore 按全局和模块级别过滤，SetModuleLevel 才能打开 debug 日志
+		cfg.Level = zap.NewAtomicLevelAt(zapcore.DebugLevel)
+		Logger, _ = cfg.Build(zap.AddStacktrace(zapcore.LevelEnabler(zapcore.ErrorLevel)),
+			zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+				return newLevelCore(core, "")
+			}))
 	}
 }
 
@@ -255,9 +333,21 @@ func NewMeta(key string, value interface{}) Meta {
 	return &meta{key: key, value: value}
 }
 
-// WrapMeta wrap meta to zap fields
+// fieldsError 带有结构化字段的 error，如 github.com/phper95/pkg/errors 中的 error
+type fieldsError interface {
+	Fields() map[string]interface{}
+}
+
+// WrapMeta wrap meta to zap fields,
+// err 带有结构化字段时(实现 Fields() map[string]interface{})字段也会写入 meta，同名时 metas 优先
 func WrapMeta(err error, metas ...Meta) (fields []zap.Field) {
-	capacity := len(metas) + 1 // namespace meta
+	var errFields map[string]interface{}
+	var fe fieldsError
+	if err != nil && errors.As(err, &fe) {
+		errFields = fe.Fields()
+	}
+
+	capacity := len(metas) + len(errFields) + 1 // namespace meta
 	if err != nil {
 		capacity++
 	}
@@ -268,6 +358,23 @@ func WrapMeta(err error, metas ...Meta) (fields []zap.Field) {
 	}
 
 	fields = append(fields, zap.Namespace("meta"))
+	if len(errFields) > 0 {
+		keys := make([]string, 0, len(errFields))
+		for key := range errFields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+	next:
+		for _, key := range keys {
+			for _, meta := range metas {
+				if meta.Key() == key {
+					continue next
+				}
+			}
+			fields = append(fields, zap.Any(key, errFields[key]))
+		}
+	}
 	for _, meta := range metas {
 		fields = append(fields, zap.Any(meta.Key(), meta.Value()))
 	}
@@ -1,9 +1,11 @@
 package logger
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/pkg/errors"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestJSONLogger(t *testing.T) {
@@ -21,6 +23,45 @@ func TestJSONLogger(t *testing.T) {
 
 }
 
+func TestJSONLoggerIndependent(t *testing.T) {
+	global := InitLogger()
+	logger, err := NewJSONLogger(WithWarnLevel())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Logger != global || logger == global {
+		t.Fatal("NewJSONLogger should not replace the global Logger")
+	}
+	if logger.Core().Enabled(zapcore.InfoLevel) || !logger.Core().Enabled(zapcore.WarnLevel) {
+		t.Fatal("NewJSONLogger should use its own level")
+	}
+	if !global.Core().Enabled(zapcore.InfoLevel) {
+		t.Fatal("global level should not be changed")
+	}
+
+	if _, err = NewJSONLogger(WithField("", "value")); err == nil {
+		t.Fatal("expected error for empty field key")
+	}
+	if _, err = NewJSONLogger(WithSampling(-1, 0)); err == nil {
+		t.Fatal("expected error for negative sampling")
+	}
+}
+
+func TestDefaultLoggerModuleLevel(t *testing.T) {
+	Logger = nil
+	resetModuleLoggers()
+	defer ResetModuleLevel("mq")
+
+	SetLevel(zapcore.InfoLevel)
+	SetModuleLevel("mq", zapcore.DebugLevel)
+	if Module("mq").Check(zapcore.DebugLevel, "mq debug") == nil {
+		t.Fatal("mq debug should be enabled without InitLogger")
+	}
+	if Logger.Check(zapcore.DebugLevel, "debug") != nil || Module("es").Check(zapcore.DebugLevel, "es debug") != nil {
+		t.Fatal("global debug should be disabled")
+	}
+}
+
 func BenchmarkJsonLogger(b *testing.B) {
 	b.ResetTimer()
 	logger, err := NewJSONLogger(
@@ -33,3 +74,23 @@ func BenchmarkJsonLogger(b *testing.B) {
 	defer logger.Sync()
 
 }
+
+type fieldsErr struct{}
+
+func (fieldsErr) Error() string { return "pay failed" }
+
+func (fieldsErr) Fields() map[string]interface{} {
+	return map[string]interface{}{"uid": 1, "order_id": "o1"}
+}
+
+func TestWrapMetaFields(t *testing.T) {
+	fields := WrapMeta(errors.Wrap(fieldsErr{}, "checkout"), NewMeta("uid", 2))
+
+	keys := make([]string, 0, len(fields))
+	for _, field := range fields {
+		keys = append(keys, field.Key)
+	}
+	if strings.Join(keys, ",") != "error,meta,order_id,uid" || fields[3].Integer != 2 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
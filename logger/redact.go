@@ -0,0 +1,91 @@
+package logger
+
+import (
+	"github.com/phper95/pkg/logger/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithRedactor 日志编码前按 redact.Redactor 的规则对消息和字段脱敏，例如 WithRedactor(redact.Default())
+func WithRedactor(r *redact.Redactor) Option {
+	return func(opt *option) {
+		opt.redactor = r
+	}
+}
+
+// NewRedactCore 包装 zapcore.Core，写入前对消息和字段脱敏
+func NewRedactCore(core zapcore.Core, r *redact.Redactor) zapcore.Core {
+	if r == nil {
+		return core
+	}
+	return &redactCore{Core: core, redactor: r}
+}
+
+// redactCore 写入前对消息和字段脱敏
+type redactCore struct {
+	zapcore.Core
+	redactor *redact.Redactor
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(RedactFields(c.redactor, fields)), redactor: c.redactor}
+}
+
+func (c *redactCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *redactCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ent.Message = c.redactor.String(ent.Message)
+	if checked := c.Core.Check(ent, nil); checked != nil {
+		checked.Write(RedactFields(c.redactor, fields)...)
+	}
+	return nil
+}
+
+// RedactFields 返回脱敏后的字段，不修改原字段
+func RedactFields(r *redact.Redactor, fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, field := range fields {
+		redacted[i] = redactField(r, field)
+	}
+	return redacted
+}
+
+func redactField(r *redact.Redactor, field zapcore.Field) zapcore.Field {
+	switch field.Type {
+	case zapcore.NamespaceType, zapcore.SkipType:
+		return field
+	}
+
+	if r.MatchKey(field.Key) {
+		return zap.String(field.Key, r.Mask())
+	}
+
+	switch field.Type {
+	case zapcore.StringType:
+		field.String = r.String(field.String)
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := field.Interface.([]byte); ok {
+			return zap.String(field.Key, r.String(string(b)))
+		}
+	case zapcore.ErrorType:
+		// 错误信息中可能带有 token、手机号等，按字符串脱敏
+		if err, ok := field.Interface.(error); ok {
+			return zap.String(field.Key, r.String(err.Error()))
+		}
+	case zapcore.StringerType:
+		return zap.String(field.Key, r.String(field.Interface.(interface{ String() string }).String()))
+	case zapcore.ReflectType:
+		return zap.Any(field.Key, r.Value("", field.Interface))
+	case zapcore.ObjectMarshalerType:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := field.Interface.(zapcore.ObjectMarshaler).MarshalLogObject(enc); err == nil {
+			return zap.Any(field.Key, r.Value("", enc.Fields))
+		}
+	}
+	return field
+}
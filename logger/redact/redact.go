@@ -0,0 +1,209 @@
+// Package redact 日志和 trace 输出前的敏感信息脱敏
+package redact
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultMask 脱敏后的替换值
+const DefaultMask = "******"
+
+var (
+	// DefaultKeys 默认需要脱敏的字段名(不区分大小写)
+	DefaultKeys = []string{
+		"token", "password", "passwd", "pwd", "secret", "authorization", "cookie", "phone", "mobile",
+	}
+
+	// CardNumber 银行卡号，订单号等长数字也会匹配，Default 不包含，需要时通过 WithValues(CardNumber) 添加
+	CardNumber = regexp.MustCompile(`\b[1-9]\d{12,18}\b`)
+	// IDNumber 身份证号
+	IDNumber = regexp.MustCompile(`\b[1-9]\d{16}[\dXx]\b`)
+	// Email 邮箱
+	Email = regexp.MustCompile(`[\w.+-]+@[\w-]+(\.[\w-]+)+`)
+	// PhoneNumber 手机号
+	PhoneNumber = regexp.MustCompile(`\b1[3-9]\d{9}\b`)
+)
+
+// Option 脱敏配置
+type Option func(*Redactor)
+
+// WithKeys 字段名匹配任一正则(不区分大小写)时整个值被替换
+func WithKeys(patterns ...string) Option {
+	return func(r *Redactor) {
+		for _, pattern := range patterns {
+			r.keys = append(r.keys, regexp.MustCompile("(?i)"+pattern))
+		}
+	}
+}
+
+// WithValues 字符串值中匹配任一正则的部分被替换
+func WithValues(patterns ...*regexp.Regexp) Option {
+	return func(r *Redactor) {
+		r.values = append(r.values, patterns...)
+	}
+}
+
+// WithMask 设置替换值，默认 DefaultMask
+func WithMask(mask string) Option {
+	return func(r *Redactor) {
+		r.mask = mask
+	}
+}
+
+// Redactor 按字段名和值的正则脱敏
+type Redactor struct {
+	keys   []*regexp.Regexp
+	values []*regexp.Regexp
+	mask   string
+}
+
+// New 创建 Redactor，不传参数时不做任何脱敏
+func New(options ...Option) *Redactor {
+	r := &Redactor{mask: DefaultMask}
+	for _, f := range options {
+		if f != nil {
+			f(r)
+		}
+	}
+	return r
+}
+
+// Default 使用 DefaultKeys 和身份证号、邮箱、手机号规则的 Redactor
+func Default(options ...Option) *Redactor {
+	return New(append([]Option{
+		WithKeys(DefaultKeys...),
+		WithValues(IDNumber, Email, PhoneNumber),
+	}, options...)...)
+}
+
+// Mask 返回替换值
+func (r *Redactor) Mask() string {
+	return r.mask
+}
+
+// MatchKey 字段名是否需要脱敏
+func (r *Redactor) MatchKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, re := range r.keys {
+		if re.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// String 替换字符串中匹配值规则的部分，json 字符串会按字段名脱敏
+func (r *Redactor) String(s string) string {
+	if trimmed := strings.TrimSpace(s); len(trimmed) > 1 && (trimmed[0] == '{' || trimmed[0] == '[') {
+		if v, err := decode([]byte(trimmed)); err == nil {
+			if raw, err := json.Marshal(r.walk(v)); err == nil {
+				return string(raw)
+			}
+		}
+	}
+	return r.replace(s)
+}
+
+func (r *Redactor) replace(s string) string {
+	for _, re := range r.values {
+		s = re.ReplaceAllString(s, r.mask)
+	}
+	return s
+}
+
+// Value 返回脱敏后的值，不修改原值；key 为该值的字段名，可以为空
+func (r *Redactor) Value(key string, v interface{}) interface{} {
+	if r.MatchKey(key) {
+		return r.mask
+	}
+
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return r.String(val)
+	case []byte:
+		return r.String(string(val))
+	case http.Header:
+		return r.header(val)
+	case map[string][]string:
+		return map[string][]string(r.header(val))
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v
+	}
+
+	// 其它类型转成 json 后脱敏
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	generic, err := decode(raw)
+	if err != nil {
+		return v
+	}
+	return r.walk(generic)
+}
+
+// decode 解析 json，数字保留为 json.Number 避免长数字丢失精度
+func decode(raw []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	err := dec.Decode(&v)
+	return v, err
+}
+
+func (r *Redactor) header(h map[string][]string) http.Header {
+	if h == nil {
+		return nil
+	}
+	out := make(http.Header, len(h))
+	for k, values := range h {
+		masked := make([]string, len(values))
+		for i, value := range values {
+			if r.MatchKey(k) {
+				masked[i] = r.mask
+			} else {
+				masked[i] = r.replace(value)
+			}
+		}
+		out[k] = masked
+	}
+	return out
+}
+
+func (r *Redactor) walk(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			if r.MatchKey(k) {
+				out[k] = r.mask
+				continue
+			}
+			out[k] = r.walk(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = r.walk(item)
+		}
+		return out
+	case string:
+		return r.replace(val)
+	case json.Number:
+		if masked := r.replace(val.String()); masked != val.String() {
+			return masked
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,39 @@
+package redact
+
+import (
+	"github.com/phper95/pkg/trace"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Redactor 可以直接用于 trace.SetRedactor
+var _ trace.Redactor = (*Redactor)(nil)
+
+func TestRedactor(t *testing.T) {
+	r := Default()
+
+	s := r.String("user a@b.com id 11010519900307123X phone 13800138000")
+	if strings.ContainsAny(s, "@0123456789") {
+		t.Fatalf("unexpected %s", s)
+	}
+
+	// 银行卡号需要显式开启，默认不影响订单号等长数字
+	if s := r.String("order 2023051712345678"); s != "order 2023051712345678" {
+		t.Fatalf("unexpected %s", s)
+	}
+	if s := Default(WithValues(CardNumber)).String("card 6222021234567890123"); s != "card "+DefaultMask {
+		t.Fatalf("unexpected %s", s)
+	}
+
+	body := r.String(`{"password":"123","user":{"name":"tom","id_card":110105199003071234}}`)
+	if strings.Contains(body, "123\"") || strings.Contains(body, "110105199003071234") || !strings.Contains(body, "tom") {
+		t.Fatalf("unexpected %s", body)
+	}
+
+	header := http.Header{"Authorization": {"Bearer xxx"}, "Accept": {"*/*"}}
+	masked := r.Value("", header).(http.Header)
+	if masked.Get("Authorization") != DefaultMask || masked.Get("Accept") != "*/*" || header.Get("Authorization") != "Bearer xxx" {
+		t.Fatalf("unexpected %v %v", masked, header)
+	}
+}
@@ -0,0 +1,40 @@
+package logger
+
+import (
+	"errors"
+	"github.com/phper95/pkg/logger/redact"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+)
+
+func TestRedactCore(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(NewRedactCore(core, redact.Default())).With(zap.String("token", "abc"))
+
+	l.Info("login a@b.com", WrapMeta(nil, NewMeta("password", "123"), NewMeta("body", map[string]interface{}{"mobile": "13800138000"}))...)
+
+	entry := logs.All()[0]
+	if entry.Message != "login "+redact.DefaultMask {
+		t.Fatalf("unexpected message %s", entry.Message)
+	}
+	fields := entry.ContextMap()
+	meta := fields["meta"].(map[string]interface{})
+	if fields["token"] != redact.DefaultMask || meta["password"] != redact.DefaultMask ||
+		meta["body"].(map[string]interface{})["mobile"] != redact.DefaultMask {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
+
+func TestRedactErrorField(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	l := zap.New(NewRedactCore(core, redact.Default()))
+
+	l.Error("send sms", zap.Error(errors.New("send to 13800138000 failed")), zap.NamedError("password", errors.New("123")))
+
+	fields := logs.All()[0].ContextMap()
+	if fields["error"] != "send to "+redact.DefaultMask+" failed" || fields["password"] != redact.DefaultMask {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
@@ -0,0 +1,253 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// RotateNone 只按文件大小切割
+	RotateNone RotateInterval = iota
+	// RotateHourly 每小时一个文件，文件名如 app.2006010215.log
+	RotateHourly
+	// RotateDaily 每天一个文件，文件名如 app.20060102.log
+	RotateDaily
+)
+
+// RotateInterval 按时间切割日志文件的周期
+type RotateInterval int
+
+// RotationOption 日志文件切割配置
+type RotationOption func(*rotation)
+
+type rotation struct {
+	maxSize    int
+	maxBackups int
+	maxAge     int
+	compress   bool
+	interval   RotateInterval
+	retention  time.Duration
+}
+
+// WithMaxSize 单个文件最大尺寸，单位 M，默认 128
+func WithMaxSize(mb int) RotationOption {
+	return func(r *rotation) {
+		r.maxSize = mb
+	}
+}
+
+// WithMaxBackups 最多保留的备份数，默认 300
+func WithMaxBackups(n int) RotationOption {
+	return func(r *rotation) {
+		r.maxBackups = n
+	}
+}
+
+// WithMaxAge 备份最多保留的天数，默认 30
+func WithMaxAge(days int) RotationOption {
+	return func(r *rotation) {
+		r.maxAge = days
+	}
+}
+
+// WithCompress 是否压缩备份，默认压缩
+func WithCompress(compress bool) RotationOption {
+	return func(r *rotation) {
+		r.compress = compress
+	}
+}
+
+// WithRotateInterval 按小时或天切割日志文件，文件名中带有日期
+func WithRotateInterval(interval RotateInterval) RotationOption {
+	return func(r *rotation) {
+		r.interval = interval
+	}
+}
+
+// WithRetention 按时间切割时，删除修改时间早于 d 的日志文件，默认使用 WithMaxAge 的天数，都小于等于 0 时不删除
+func WithRetention(d time.Duration) RotationOption {
+	return func(r *rotation) {
+		r.retention = d
+	}
+}
+
+func newRotation(options ...RotationOption) *rotation {
+	r := &rotation{
+		maxSize:    128,
+		maxBackups: 300,
+		maxAge:     30,
+		compress:   true,
+	}
+	for _, f := range options {
+		if f != nil {
+			f(r)
+		}
+	}
+	if r.retention <= 0 && r.maxAge > 0 {
+		r.retention = time.Duration(r.maxAge) * 24 * time.Hour
+	}
+	return r
+}
+
+func (r *rotation) lumberjack(file string) *lumberjack.Logger {
+	return &lumberjack.Logger{ // concurrent-safed
+		Filename:   file,         // 文件路径
+		MaxSize:    r.maxSize,    // 单个文件最大尺寸，默认单位 M
+		MaxBackups: r.maxBackups, // 最多保留的备份数
+		MaxAge:     r.maxAge,     // 最大时间，默认单位 day
+		LocalTime:  true,         // 使用本地时间
+		Compress:   r.compress,   // 是否压缩
+	}
+}
+
+// newRotateWriter 按配置创建切割日志文件的 writer
+func newRotateWriter(file string, options ...RotationOption) io.Writer {
+	dir := filepath.Dir(file)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		panic(err)
+	}
+
+	r := newRotation(options...)
+	if r.interval == RotateNone {
+		return r.lumberjack(file)
+	}
+
+	ext := filepath.Ext(file)
+	return &timeRotateWriter{
+		rotation: r,
+		prefix:   strings.TrimSuffix(file, ext) + ".",
+		ext:      ext,
+	}
+}
+
+// timeRotateWriter 按时间切割日志文件，每个周期内仍按大小切割
+type timeRotateWriter struct {
+	*rotation
+	prefix string
+	ext    string
+
+	mux    sync.Mutex
+	period string
+	writer *lumberjack.Logger
+}
+
+func (w *timeRotateWriter) layout() string {
+	if w.interval == RotateHourly {
+		return "2006010215"
+	}
+	return "20060102"
+}
+
+func (w *timeRotateWriter) periodOf(t time.Time) string {
+	return t.Format(w.layout())
+}
+
+// isRotated 是否为该 writer 生成的日志文件：prefix + 日期 + ext，
+// 或 lumberjack 按大小切割的备份 prefix + 日期 + "-" + 时间 + ext，可能带有 .gz
+func (w *timeRotateWriter) isRotated(file string) bool {
+	layout := w.layout()
+	name := strings.TrimPrefix(file, w.prefix)
+	if len(name) < len(layout) {
+		return false
+	}
+	if _, err := time.ParseInLocation(layout, name[:len(layout)], time.Local); err != nil {
+		return false
+	}
+
+	rest := name[len(layout):]
+	if rest == w.ext {
+		return true
+	}
+	rest = strings.TrimSuffix(rest, ".gz")
+	return strings.HasPrefix(rest, "-") && strings.HasSuffix(rest, w.ext)
+}
+
+func (w *timeRotateWriter) Write(p []byte) (int, error) {
+	w.mux.Lock()
+	defer w.mux.Unlock()
+
+	if period := w.periodOf(time.Now()); period != w.period {
+		if w.writer != nil {
+			w.writer.Close()
+		}
+		w.period = period
+		w.writer = w.lumberjack(w.prefix + period + w.ext)
+		if w.retention > 0 {
+			go w.clean()
+		}
+	}
+	return w.writer.Write(p)
+}
+
+func (w *timeRotateWriter) Sync() error {
+	return nil
+}
+
+// clean 删除过期的日志文件，包括压缩后的备份，retention 小于等于 0 时不删除
+func (w *timeRotateWriter) clean() {
+	if w.retention <= 0 {
+		return
+	}
+
+	files, err := filepath.Glob(w.prefix + "[0-9]*")
+	if err != nil {
+		return
+	}
+
+	deadline := time.Now().Add(-w.retention)
+	for _, file := range files {
+		if !w.isRotated(file) {
+			continue
+		}
+		info, err := os.Stat(file)
+		if err != nil || info.IsDir() || !info.ModTime().Before(deadline) {
+			continue
+		}
+		os.Remove(file)
+	}
+}
+
+// levelFile 按级别写入的日志文件
+type levelFile struct {
+	level  zapcore.Level
+	writer io.Writer
+}
+
+// WithLevelFileRotationP 级别大于等于 lvl 的日志写入 file，直到下一个配置的级别为止，例如：
+// WithLevelFileRotationP(zapcore.InfoLevel, "info.log") 和 WithLevelFileRotationP(zapcore.ErrorLevel, "error.log")
+// 时 info、warn 写入 info.log，error 及以上写入 error.log
+func WithLevelFileRotationP(lvl zapcore.Level, file string, options ...RotationOption) Option {
+	writer := newRotateWriter(file, options...)
+
+	return func(opt *option) {
+		opt.levelFiles = append(opt.levelFiles, levelFile{level: lvl, writer: writer})
+	}
+}
+
+// levelFileCores 按级别分文件写入的 core
+func levelFileCores(encoder zapcore.Encoder, files []levelFile) []zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(files))
+	for _, lf := range files {
+		lf := lf
+		upper := zapcore.Level(zapcore.FatalLevel + 1)
+		for _, other := range files {
+			if other.level > lf.level && other.level < upper {
+				upper = other.level
+			}
+		}
+
+		cores = append(cores, zapcore.NewCore(encoder, zapcore.AddSync(lf.writer),
+			zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+				return lvl >= lf.level && lvl < upper
+			}),
+		))
+	}
+	return cores
+}
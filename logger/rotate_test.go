@@ -0,0 +1,98 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTimeRotateWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	expired := filepath.Join(dir, "app.20200101.log")
+	ioutil.WriteFile(expired, []byte("old"), 0644)
+	os.Chtimes(expired, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+
+	w := newRotateWriter(filepath.Join(dir, "app.log"), WithRotateInterval(RotateDaily), WithRetention(24*time.Hour))
+	w.Write([]byte("hello\n"))
+	w.(*timeRotateWriter).clean()
+
+	if _, err := os.Stat(filepath.Join(dir, "app."+time.Now().Format("20060102")+".log")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(expired); !os.IsNotExist(err) {
+		t.Fatalf("expired file should be removed, got %v", err)
+	}
+}
+
+func TestTimeRotateWriterClean(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	old := time.Now().Add(-48 * time.Hour)
+	touch := func(name string) string {
+		file := filepath.Join(dir, name)
+		ioutil.WriteFile(file, []byte("old"), 0644)
+		os.Chtimes(file, old, old)
+		return file
+	}
+	backup := touch("app.20200101-2020-01-01T10-00-00.000.log.gz")
+	sibling := touch("app.2020010199.log")
+	other := touch("app.20200101.bak")
+
+	// maxAge 为 0 时不删除
+	forever := newRotateWriter(filepath.Join(dir, "app.log"), WithRotateInterval(RotateDaily), WithMaxAge(0))
+	forever.(*timeRotateWriter).clean()
+	if _, err := os.Stat(backup); err != nil {
+		t.Fatalf("backup should be kept, got %v", err)
+	}
+
+	w := newRotateWriter(filepath.Join(dir, "app.log"), WithRotateInterval(RotateDaily), WithRetention(24*time.Hour))
+	w.(*timeRotateWriter).clean()
+	if _, err := os.Stat(backup); !os.IsNotExist(err) {
+		t.Fatalf("expired backup should be removed, got %v", err)
+	}
+	for _, file := range []string{sibling, other} {
+		if _, err := os.Stat(file); err != nil {
+			t.Fatalf("unrelated file %s should be kept, got %v", file, err)
+		}
+	}
+}
+
+func TestLevelFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "logger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	opt := &option{}
+	WithLevelFileRotationP(zapcore.InfoLevel, filepath.Join(dir, "info.log"))(opt)
+	WithLevelFileRotationP(zapcore.ErrorLevel, filepath.Join(dir, "error.log"))(opt)
+
+	l := zap.New(zapcore.NewTee(levelFileCores(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), opt.levelFiles)...))
+	l.Debug("debug msg")
+	l.Warn("warn msg")
+	l.Error("error msg")
+
+	info, _ := ioutil.ReadFile(filepath.Join(dir, "info.log"))
+	errs, _ := ioutil.ReadFile(filepath.Join(dir, "error.log"))
+	if !strings.Contains(string(info), "warn msg") || strings.Contains(string(info), "error msg") || strings.Contains(string(info), "debug msg") {
+		t.Fatalf("unexpected info.log %s", info)
+	}
+	if !strings.Contains(string(errs), "error msg") || strings.Contains(string(errs), "warn msg") {
+		t.Fatalf("unexpected error.log %s", errs)
+	}
+}
@@ -0,0 +1,265 @@
+package logger
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// dropped 因采样和限流丢弃的日志条数
+var dropped uint64
+
+// Dropped 返回因采样和限流丢弃的日志条数
+func Dropped() uint64 {
+	return atomic.LoadUint64(&dropped)
+}
+
+// WithSampling 日志采样：每秒内同一级别同一消息的日志，前 first 条全部输出，之后每 thereafter 条输出一条
+func WithSampling(first, thereafter int) Option {
+	return func(opt *option) {
+		opt.samplingFirst = first
+		opt.samplingThereafter = thereafter
+	}
+}
+
+// WithRateLimit 按 key 限流：每个 interval 内同一 key 最多输出 limit 条日志，
+// key 由 logger 名称、消息和 keyFields 对应的字段值组成；
+// 被限流的条数会以 dropped 字段附加在该 key 下一条输出的日志中
+func WithRateLimit(limit int, interval time.Duration, keyFields ...string) Option {
+	return func(opt *option) {
+		opt.rateLimit = limit
+		opt.rateLimitInterval = interval
+		opt.rateLimitKeyFields = keyFields
+	}
+}
+
+// WithDedup 日志去重：window 时间内重复的同一级别同一消息只输出第一条，
+// window 结束时输出一条带 repeated 字段的汇总日志
+func WithDedup(window time.Duration) Option {
+	return func(opt *option) {
+		opt.dedupWindow = window
+	}
+}
+
+// wrapSampling 按配置依次包装采样、去重、限流
+func wrapSampling(core zapcore.Core, opt *option) zapcore.Core {
+	if opt.rateLimit > 0 && opt.rateLimitInterval > 0 {
+		core = &rateLimitCore{
+			Core:      core,
+			state:     &rateLimitState{limit: opt.rateLimit, interval: opt.rateLimitInterval, keys: make(map[string]*rateLimitCounter)},
+			keyFields: opt.rateLimitKeyFields,
+		}
+	}
+
+	if opt.dedupWindow > 0 {
+		core = &dedupCore{
+			Core:  core,
+			state: &dedupState{window: opt.dedupWindow, keys: make(map[string]*dedupCounter)},
+		}
+	}
+
+	if opt.samplingFirst > 0 || opt.samplingThereafter > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, opt.samplingFirst, opt.samplingThereafter,
+			zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+				if dec&zapcore.LogDropped > 0 {
+					atomic.AddUint64(&dropped, 1)
+				}
+			}))
+	}
+	return core
+}
+
+type rateLimitCounter struct {
+	start   time.Time
+	count   int
+	dropped int
+}
+
+type rateLimitState struct {
+	mux      sync.Mutex
+	limit    int
+	interval time.Duration
+	keys     map[string]*rateLimitCounter
+}
+
+// allow 返回是否允许输出，以及之前被限流的条数
+func (s *rateLimitState) allow(key string, ts time.Time) (bool, int) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	counter, ok := s.keys[key]
+	if !ok || ts.Sub(counter.start) >= s.interval {
+		if !ok && len(s.keys) >= 10000 {
+			// 防止 key 过多占用内存，清理已过期的 key
+			for k, c := range s.keys {
+				if ts.Sub(c.start) >= s.interval {
+					delete(s.keys, k)
+				}
+			}
+		}
+		if !ok {
+			counter = &rateLimitCounter{}
+			s.keys[key] = counter
+		}
+		counter.start = ts
+		counter.count = 0
+	}
+
+	if counter.count >= s.limit {
+		counter.dropped++
+		return false, 0
+	}
+
+	counter.count++
+	n := counter.dropped
+	counter.dropped = 0
+	return true, n
+}
+
+// rateLimitCore 按 key 限流
+type rateLimitCore struct {
+	zapcore.Core
+	state     *rateLimitState
+	keyFields []string
+}
+
+func (c *rateLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimitCore{Core: c.Core.With(fields), state: c.state, keyFields: c.keyFields}
+}
+
+func (c *rateLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+	return ce.AddCore(ent, c)
+}
+
+func (c *rateLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	key := ent.LoggerName + "|" + ent.Message
+	if len(c.keyFields) > 0 {
+		var b strings.Builder
+		b.WriteString(key)
+		for _, name := range c.keyFields {
+			for _, field := range fields {
+				if field.Key == name {
+					b.WriteString("|")
+					b.WriteString(fieldValue(field))
+					break
+				}
+			}
+		}
+		key = b.String()
+	}
+
+	ok, n := c.state.allow(key, ent.Time)
+	if !ok {
+		atomic.AddUint64(&dropped, 1)
+		return nil
+	}
+	if n > 0 {
+		fields = append(fields[:len(fields):len(fields)], zap.Int("dropped", n))
+	}
+
+	if checked := c.Core.Check(ent, nil); checked != nil {
+		checked.Write(fields...)
+	}
+	return nil
+}
+
+func fieldValue(field zapcore.Field) string {
+	switch {
+	case field.String != "":
+		return field.String
+	case field.Interface != nil:
+		return fmt.Sprint(field.Interface)
+	default:
+		return fmt.Sprint(field.Integer)
+	}
+}
+
+type dedupCounter struct {
+	core     zapcore.Core
+	entry    zapcore.Entry
+	repeated int
+}
+
+type dedupState struct {
+	mux    sync.Mutex
+	window time.Duration
+	keys   map[string]*dedupCounter
+}
+
+// flush window 结束时输出汇总日志
+func (s *dedupState) flush(key string, counter *dedupCounter) {
+	s.mux.Lock()
+	if s.keys[key] != counter {
+		s.mux.Unlock()
+		return
+	}
+	delete(s.keys, key)
+	repeated := counter.repeated
+	s.mux.Unlock()
+
+	if repeated == 0 {
+		return
+	}
+
+	ent := counter.entry
+	ent.Time = time.Now()
+	ent.Message = fmt.Sprintf("%s (repeated %d times in %v)", ent.Message, repeated, s.window)
+	if checked := counter.core.Check(ent, nil); checked != nil {
+		checked.Write(zap.Int("repeated", repeated))
+	}
+}
+
+// dedupCore window 时间内重复的日志只输出一次，结束时输出汇总
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{Core: c.Core.With(fields), state: c.state}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	key := ent.Level.String() + "|" + ent.LoggerName + "|" + ent.Message
+	s := c.state
+	s.mux.Lock()
+	if counter, ok := s.keys[key]; ok {
+		counter.repeated++
+		s.mux.Unlock()
+		return ce
+	}
+	counter := &dedupCounter{core: c.Core, entry: ent}
+	s.keys[key] = counter
+	s.mux.Unlock()
+
+	time.AfterFunc(s.window, func() { s.flush(key, counter) })
+	return c.Core.Check(ent, ce)
+}
+
+func (c *dedupCore) Sync() error {
+	s := c.state
+	s.mux.Lock()
+	counters := make(map[string]*dedupCounter)
+	for key, counter := range s.keys {
+		if counter.repeated > 0 {
+			counters[key] = counter
+		}
+	}
+	s.mux.Unlock()
+
+	for key, counter := range counters {
+		s.flush(key, counter)
+	}
+	return c.Core.Sync()
+}
@@ -0,0 +1,56 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"testing"
+	"time"
+)
+
+func newSampledLogger(opts ...Option) (*zap.Logger, *observer.ObservedLogs) {
+	opt := &option{}
+	for _, f := range opts {
+		f(opt)
+	}
+	core, logs := observer.New(zapcore.DebugLevel)
+	return zap.New(wrapSampling(core, opt)), logs
+}
+
+func TestSampling(t *testing.T) {
+	l, logs := newSampledLogger(WithSampling(3, 10))
+	for i := 0; i < 23; i++ {
+		l.Error("db down")
+	}
+	if logs.Len() != 5 {
+		t.Fatalf("expect 5 logs, got %d", logs.Len())
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	l, logs := newSampledLogger(WithRateLimit(2, time.Hour, "uid"))
+	for i := 0; i < 5; i++ {
+		l.Error("login failed", zap.Int("uid", 1))
+		l.Error("login failed", zap.Int("uid", 2))
+	}
+	if logs.Len() != 4 {
+		t.Fatalf("expect 4 logs, got %d", logs.Len())
+	}
+}
+
+func TestDedup(t *testing.T) {
+	l, logs := newSampledLogger(WithDedup(time.Hour))
+	for i := 0; i < 5; i++ {
+		l.Error("db down")
+	}
+	l.Warn("db down")
+	if logs.Len() != 2 {
+		t.Fatalf("expect 2 logs, got %d", logs.Len())
+	}
+
+	l.Sync()
+	entries := logs.All()
+	if len(entries) != 3 || entries[2].ContextMap()["repeated"] != int64(4) {
+		t.Fatalf("expect summary log, got %v", entries)
+	}
+}
@@ -0,0 +1,331 @@
+package logger
+
+import (
+	"bytes"
+	"fmt"
+	"go.uber.org/zap/zapcore"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultSinkBuffer 默认缓冲的日志条数，缓冲满时丢弃新日志
+	DefaultSinkBuffer = 4096
+	// DefaultSinkBatchSize 默认每批发送的日志条数
+	DefaultSinkBatchSize = 100
+	// DefaultSinkFlushInterval 默认发送间隔
+	DefaultSinkFlushInterval = time.Second
+)
+
+// SinkOption 异步发送日志的配置
+type SinkOption func(*sinkOption)
+
+type sinkOption struct {
+	buffer        int
+	batchSize     int
+	flushInterval time.Duration
+	exclude       map[string]bool
+}
+
+// WithSinkBuffer 设置缓冲的日志条数，缓冲满时丢弃新日志，不会阻塞业务
+func WithSinkBuffer(size int) SinkOption {
+	return func(opt *sinkOption) {
+		opt.buffer = size
+	}
+}
+
+// WithSinkBatch 设置每批发送的最大条数和最长等待时间
+func WithSinkBatch(size int, interval time.Duration) SinkOption {
+	return func(opt *sinkOption) {
+		opt.batchSize = size
+		opt.flushInterval = interval
+	}
+}
+
+// WithSinkExclude 不发送这些模块(logger 名称)的日志，避免发送日志时产生的日志循环发送
+func WithSinkExclude(modules ...string) SinkOption {
+	return func(opt *sinkOption) {
+		for _, module := range modules {
+			opt.exclude[module] = true
+		}
+	}
+}
+
+// SinkWriteFunc 批量写入一批 json 格式的日志，每条日志不包含换行符
+type SinkWriteFunc func(lines [][]byte) error
+
+// Sink 异步批量发送日志，缓冲满时丢弃日志并计数，发送失败时计数
+type Sink struct {
+	opt     *sinkOption
+	write   SinkWriteFunc
+	lines   chan []byte
+	dropped uint64
+	failed  uint64
+
+	// mux 保护 closed，避免 Close 之后 Write 向已关闭的 lines 发送
+	mux    sync.RWMutex
+	closed bool
+	done   chan struct{}
+
+	// closer 发送完缓冲中的日志后释放资源，如 syslog 连接
+	closer    func() error
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewSink 创建异步发送日志的 Sink，通过 WithSink 添加到 InitLogger；
+// 缓冲大小、每批条数、发送间隔小于等于 0 时使用默认值
+func NewSink(write SinkWriteFunc, options ...SinkOption) *Sink {
+	opt := &sinkOption{
+		buffer:        DefaultSinkBuffer,
+		batchSize:     DefaultSinkBatchSize,
+		flushInterval: DefaultSinkFlushInterval,
+		exclude:       make(map[string]bool),
+	}
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	if opt.buffer <= 0 {
+		opt.buffer = DefaultSinkBuffer
+	}
+	if opt.batchSize <= 0 {
+		opt.batchSize = DefaultSinkBatchSize
+	}
+	if opt.flushInterval <= 0 {
+		opt.flushInterval = DefaultSinkFlushInterval
+	}
+
+	s := &Sink{
+		opt:   opt,
+		write: write,
+		lines: make(chan []byte, opt.buffer),
+		done:  make(chan struct{}),
+	}
+	go s.run()
+	return s
+}
+
+// Write 实现 io.Writer，复制日志后放入缓冲，缓冲满或已经 Close 时丢弃
+func (s *Sink) Write(p []byte) (int, error) {
+	line := make([]byte, len(p))
+	copy(line, p)
+	line = bytes.TrimRight(line, "\n")
+
+	s.mux.RLock()
+	defer s.mux.RUnlock()
+
+	if s.closed {
+		atomic.AddUint64(&s.dropped, 1)
+		return len(p), nil
+	}
+	select {
+	case s.lines <- line:
+	default:
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return len(p), nil
+}
+
+// Sync 异步发送，不等待
+func (s *Sink) Sync() error {
+	return nil
+}
+
+// Close 停止接收日志，发送完缓冲中的日志后释放连接等资源再返回，之后写入的日志计入 Dropped
+func (s *Sink) Close() error {
+	s.mux.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.lines)
+	}
+	s.mux.Unlock()
+
+	<-s.done
+	s.closeOnce.Do(func() {
+		if s.closer != nil {
+			s.closeErr = s.closer()
+		}
+	})
+	return s.closeErr
+}
+
+// Dropped 缓冲满或 Close 之后丢弃的日志条数
+func (s *Sink) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Failed 发送失败的日志条数
+func (s *Sink) Failed() uint64 {
+	return atomic.LoadUint64(&s.failed)
+}
+
+func (s *Sink) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.opt.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([][]byte, 0, s.opt.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.write(batch); err != nil {
+			atomic.AddUint64(&s.failed, uint64(len(batch)))
+			fmt.Fprintf(os.Stderr, "logger sink write %d lines err: %v\n", len(batch), err)
+		}
+		batch = make([][]byte, 0, s.opt.batchSize)
+	}
+
+	for {
+		select {
+		case line, ok := <-s.lines:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, line)
+			if len(batch) >= s.opt.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// WithSink 日志以 json 格式异步发送到 sink，不受 WithDisableConsole 影响
+func WithSink(sink *Sink) Option {
+	return func(opt *option) {
+		opt.sinks = append(opt.sinks, sink)
+	}
+}
+
+// sinkCore 过滤不发送的模块日志
+type sinkCore struct {
+	zapcore.Core
+	exclude map[string]bool
+}
+
+func (c *sinkCore) With(fields []zapcore.Field) zapcore.Core {
+	return &sinkCore{Core: c.Core.With(fields), exclude: c.exclude}
+}
+
+func (c *sinkCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.exclude[ent.LoggerName] {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+func newSinkCore(encoder zapcore.Encoder, sink *Sink) zapcore.Core {
+	core := zapcore.NewCore(encoder, sink, zapcore.DebugLevel)
+	if len(sink.opt.exclude) == 0 {
+		return core
+	}
+	return &sinkCore{Core: core, exclude: sink.opt.exclude}
+}
+
+// NewHTTPSink 批量 POST 日志到 url，body 为每行一条 json 的 application/x-ndjson
+func NewHTTPSink(url string, options ...SinkOption) *Sink {
+	client := &http.Client{Timeout: 10 * time.Second}
+
+	return NewSink(func(lines [][]byte) error {
+		body := bytes.Join(lines, []byte("\n"))
+		resp, err := client.Post(url, "application/x-ndjson", bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("http sink got status %d", resp.StatusCode)
+		}
+		return nil
+	}, options...)
+}
+
+// syslog severity
+const (
+	syslogFacilityUser = 1
+	syslogError        = 3
+	syslogWarning      = 4
+	syslogInfo         = 6
+	syslogDebug        = 7
+)
+
+// NewSyslogSink 通过 udp 或 tcp 发送 RFC 3164 格式的日志到 syslog，tag 为空时使用程序名，
+// 不再使用时调用 Close 关闭连接
+func NewSyslogSink(network, addr, tag string, options ...SinkOption) (*Sink, error) {
+	if network != "udp" && network != "tcp" {
+		return nil, fmt.Errorf("syslog network must be udp or tcp, got %s", network)
+	}
+	if tag == "" {
+		tag = filepath.Base(os.Args[0])
+	}
+	hostname, _ := os.Hostname()
+
+	var conn net.Conn
+	dial := func() error {
+		var err error
+		conn, err = net.DialTimeout(network, addr, 5*time.Second)
+		return err
+	}
+	if err := dial(); err != nil {
+		return nil, err
+	}
+
+	pid := os.Getpid()
+	sink := NewSink(func(lines [][]byte) error {
+		if conn == nil {
+			if err := dial(); err != nil {
+				return err
+			}
+		}
+
+		var buf bytes.Buffer
+		for _, line := range lines {
+			buf.Reset()
+			fmt.Fprintf(&buf, "<%d>%s %s %s[%d]: %s\n",
+				syslogFacilityUser*8+syslogSeverity(line), time.Now().Format(time.Stamp), hostname, tag, pid, line)
+
+			conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+			if _, err := conn.Write(buf.Bytes()); err != nil {
+				conn.Close()
+				conn = nil
+				return err
+			}
+		}
+		return nil
+	}, options...)
+
+	// Close 等待发送协程退出后才调用，不会与发送并发访问 conn
+	sink.closer = func() error {
+		if conn == nil {
+			return nil
+		}
+		return conn.Close()
+	}
+	return sink, nil
+}
+
+// syslogSeverity 根据日志中的 level 字段返回 syslog severity
+func syslogSeverity(line []byte) int {
+	switch {
+	case bytes.Contains(line, []byte(`"level":"debug"`)):
+		return syslogDebug
+	case bytes.Contains(line, []byte(`"level":"info"`)):
+		return syslogInfo
+	case bytes.Contains(line, []byte(`"level":"warn"`)):
+		return syslogWarning
+	default:
+		return syslogError
+	}
+}
@@ -0,0 +1,128 @@
+package logger
+
+import (
+	"bufio"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHTTPSink(t *testing.T) {
+	var received int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			atomic.AddInt32(&received, 1)
+		}
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPSink(srv.URL, WithSinkBatch(10, time.Hour), WithSinkExclude("mq"))
+	l := zap.New(newSinkCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), sink))
+	for i := 0; i < 25; i++ {
+		l.Info("hello", zap.Int("i", i))
+		l.Named("mq").Info("excluded")
+	}
+	sink.Close()
+
+	if n := atomic.LoadInt32(&received); n != 25 || sink.Failed() != 0 {
+		t.Fatalf("expect 25 lines, got %d failed %d", n, sink.Failed())
+	}
+}
+
+func TestSinkDropped(t *testing.T) {
+	block := make(chan struct{})
+	sink := NewSink(func(lines [][]byte) error {
+		<-block
+		return nil
+	}, WithSinkBuffer(2), WithSinkBatch(1, time.Hour))
+
+	for i := 0; i < 10; i++ {
+		sink.Write([]byte("line\n"))
+	}
+	close(block)
+	sink.Close()
+
+	if sink.Dropped() == 0 {
+		t.Fatal("expect dropped lines")
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.Write([]byte(`{"level":"warn","msg":"disk full"}` + "\n"))
+	sink.Close()
+
+	buf := make([]byte, 1024)
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line := string(buf[:n]); !strings.HasPrefix(line, "<12>") || !strings.Contains(line, `app[`) || !strings.Contains(line, "disk full") {
+		t.Fatalf("unexpected syslog line %s", line)
+	}
+}
+
+func TestSyslogSinkClose(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	sink, err := NewSyslogSink("tcp", ln.Addr().String(), "app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink.Write([]byte(`{"level":"info","msg":"bye"}` + "\n"))
+	if err = sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	data, err := ioutil.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("syslog connection should be closed, got %v", err)
+	}
+	if !strings.Contains(string(data), "bye") {
+		t.Fatalf("unexpected syslog data %s", data)
+	}
+}
+
+func TestSinkWriteAfterClose(t *testing.T) {
+	sink := NewSink(func(lines [][]byte) error { return nil }, WithSinkBuffer(-1), WithSinkBatch(-1, 0))
+	l := zap.New(newSinkCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), sink))
+	sink.Close()
+	sink.Close()
+
+	l.Info("after close")
+	if sink.Dropped() != 1 {
+		t.Fatalf("expect 1 dropped line, got %d", sink.Dropped())
+	}
+}
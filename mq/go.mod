@@ -13,7 +13,9 @@ require (
 	github.com/golang/snappy v0.0.4 // indirect
 	github.com/onsi/ginkgo v1.12.0 // indirect
 	github.com/onsi/gomega v1.9.0 // indirect
+	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
 	github.com/phper95/pkg/logger v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/trace v0.0.0-20230517145757-27be2fc31eea
 	github.com/pierrec/lz4 v2.6.1+incompatible // indirect
 	github.com/pkg/errors v0.9.1
 	github.com/rcrowley/go-metrics v0.0.0-20201227073835-cf1acfcdf475 // indirect
@@ -1,7 +1,9 @@
 package mq
 
 import (
+	"context"
 	"github.com/phper95/pkg/logger"
+	"github.com/phper95/pkg/trace"
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
 	"os"
@@ -38,6 +40,24 @@ type Consumer struct {
 // KafkaMessageHandler  消费者回调函数
 type KafkaMessageHandler func(message *sarama.ConsumerMessage) (bool, error)
 
+// MessageContext 返回带有消息 trace ID、topic、partition、offset 日志字段的 context，
+// trace ID 从消息 header trace.Header 中读取，没有时使用 traceparent 中的 trace-id
+func MessageContext(msg *sarama.ConsumerMessage) context.Context {
+	ctx := logger.WithContext(context.Background(),
+		zap.String("topic", msg.Topic),
+		zap.Int32("partition", msg.Partition),
+		zap.Int64("offset", msg.Offset),
+	)
+	carrier := consumerHeaderCarrier{msg: msg}
+	if id := carrier.Get(trace.Header); id != "" {
+		return logger.WithTraceID(ctx, id)
+	}
+	if id, _, ok := trace.ParseTraceparent(carrier.Get(trace.HeaderTraceparent)); ok {
+		return logger.WithTraceID(ctx, id)
+	}
+	return ctx
+}
+
 // kafka 消费者配置
 func getKafkaDefaultConsumerConfig() (config *cluster.Config) {
 	config = cluster.NewConfig()
@@ -78,7 +98,7 @@ func StartKafkaConsumer(hosts, topics []string, groupID string, config *cluster.
 		return consumer, err
 	} else {
 		consumer.status = KafkaConsumerConnected
-		logger.Info("kafka consumer started", zap.Any(groupID, topics))
+		logger.Module("mq").Info("kafka consumer started", zap.Any(groupID, topics))
 	}
 	go consumer.consumerMessage(f)
 
@@ -100,7 +120,7 @@ func (c *Consumer) consumerMessage(f KafkaMessageHandler) {
 	for !c.exit {
 		if c.status != KafkaConsumerConnected {
 			time.Sleep(time.Second * 5)
-			logger.Warn("kafka consumer status " + c.status)
+			logger.Module("mq").Warn("kafka consumer status " + c.status)
 			continue
 		}
 
@@ -111,7 +131,7 @@ func (c *Consumer) consumerMessage(f KafkaMessageHandler) {
 		// handle notifications
 		go func() {
 			for ntf := range c.consumer.Notifications() {
-				logger.Warn("kafka consumer Rebalanced ", zap.Any(c.groupID, ntf))
+				logger.Module("mq").Warn("kafka consumer Rebalanced ", zap.Any(c.groupID, ntf))
 			}
 		}()
 
@@ -126,12 +146,12 @@ func (c *Consumer) consumerMessage(f KafkaMessageHandler) {
 						c.consumer.MarkOffset(msg, "") // mark message as processed
 					} else {
 						if err != nil {
-							logger.Error("kafka consumer msg error ", zap.Error(err))
+							logger.ModuleFromContext(MessageContext(msg), "mq").Error("kafka consumer msg error ", zap.Error(err))
 						}
 					}
 				}
 			case err := <-c.consumer.Errors():
-				logger.Error("kafka consumer msg error ", zap.Error(err))
+				logger.Module("mq").Error("kafka consumer msg error ", zap.Error(err))
 				//需要捕获 kafka 中断信息
 				if errors.Is(err, sarama.ErrOutOfBrokers) || errors.Is(err, sarama.ErrNotConnected) {
 					c.statusLock.Lock()
@@ -141,17 +161,17 @@ func (c *Consumer) consumerMessage(f KafkaMessageHandler) {
 					c.statusLock.Unlock()
 				} else {
 					// 如果不是中断信息,认为kafka挂了,进程退出
-					logger.Error("kafka server error:", zap.Error(err))
+					logger.Module("mq").Error("kafka server error:", zap.Error(err))
 				}
 			case s := <-signals:
 				// 收到系统消息先打印
-				logger.Warn("kafka consumer receive system signal" + s.String())
+				logger.Module("mq").Warn("kafka consumer receive system signal" + s.String())
 				c.statusLock.Lock()
 				c.exit = true
 				//退出前先安全关闭
 				err := c.consumer.Close()
 				if err != nil {
-					logger.Error("consumer.Close error", zap.Error(err))
+					logger.Module("mq").Error("consumer.Close error", zap.Error(err))
 				}
 				c.statusLock.Unlock()
 				break ConsumerLoop
@@ -0,0 +1,24 @@
+package mq
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/phper95/pkg/logger"
+)
+
+// NewKafkaLogSink 通过异步生产者把 json 格式的日志发送到 topic，
+// 使用 logger.WithSink 添加到 logger.InitLogger；mq 模块自身的日志不会发送，避免循环
+func NewKafkaLogSink(producer *AsyncProducer, topic string, options ...logger.SinkOption) *logger.Sink {
+	options = append([]logger.SinkOption{logger.WithSinkExclude("mq")}, options...)
+
+	return logger.NewSink(func(lines [][]byte) error {
+		for _, line := range lines {
+			if err := producer.Send(&sarama.ProducerMessage{
+				Topic: topic,
+				Value: sarama.ByteEncoder(line),
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, options...)
+}
@@ -4,7 +4,7 @@ import (
 	"github.com/phper95/pkg/logger"
 	"github.com/Shopify/sarama"
 	"github.com/eapache/go-resiliency/breaker"
-	"github.com/pkg/errors"
+	"github.com/phper95/pkg/errors"
 	"go.uber.org/zap"
 	"log"
 	"os"
@@ -129,7 +129,7 @@ func InitSyncKafkaProducer(name string, hosts []string, config *sarama.Config) e
 		syncProducer.ReConnect = make(chan bool)
 		syncProducer.SyncProducer = &producer
 		syncProducer.Status = KafkaProducerConnected
-		logger.Info("SyncKakfaProducer connected name " + name)
+		logger.Module("mq").Info("SyncKakfaProducer connected name " + name)
 	}
 	go syncProducer.keepConnect()
 	go syncProducer.check()
@@ -273,6 +273,8 @@ func (syncProducer *SyncProducer) check() {
 }
 
 //SendMsgs 同步发送消息到 kafka
+//
+// Deprecated: 使用 SendBatch，部分消息失败时返回 *errors.MultiError，可以通过 Index 找到失败的消息
 func (syncProducer *SyncProducer) SendMessages(mses []*sarama.ProducerMessage) sarama.ProducerErrors {
 	if syncProducer.Status != KafkaProducerConnected {
 		return sarama.ProducerErrors{&sarama.ProducerError{Err: errors.New("kafka syncProducer " + syncProducer.Status)}}
@@ -300,6 +302,30 @@ func (syncProducer *SyncProducer) SendMessages(mses []*sarama.ProducerMessage) s
 	return nil
 }
 
+// SendBatch 同步发送消息到 kafka，部分消息失败时返回 *errors.MultiError，Index 为消息在 mses 中的下标，
+// 错误为 *sarama.ProducerError，不属于某条消息的错误 Index 为 -1
+func (syncProducer *SyncProducer) SendBatch(mses []*sarama.ProducerMessage) error {
+	errs := syncProducer.SendMessages(mses)
+	if len(errs) == 0 {
+		return nil
+	}
+
+	indexes := make(map[*sarama.ProducerMessage]int, len(mses))
+	for i, msg := range mses {
+		indexes[msg] = i
+	}
+
+	multiErr := errors.NewMultiError()
+	for _, err := range errs {
+		index, ok := indexes[err.Msg]
+		if !ok {
+			index = -1
+		}
+		multiErr.Add(index, err)
+	}
+	return multiErr.ErrorOrNil()
+}
+
 //SendMsg 同步发送消息到 kafka
 func (syncProducer *SyncProducer) Send(msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
 	if syncProducer.Status != KafkaProducerConnected {
@@ -365,7 +391,7 @@ func (asyncProducer *AsyncProducer) keepConnect() {
 						asyncProducer.Status = KafkaProducerConnected
 					}
 					asyncProducer.StatusLock.Unlock()
-					logger.Info("kafka syncProducer ReConnected, name:" + asyncProducer.Name)
+					logger.Module("mq").Info("kafka syncProducer ReConnected, name:" + asyncProducer.Name)
 					break asyncBreakLoop
 				case breaker.ErrBreakerOpen:
 					KafkaStdLogger.Println("kafka connect fail, broker is open")
@@ -405,7 +431,7 @@ func (asyncProducer *AsyncProducer) check() {
 		for {
 			select {
 			case msg := <-(*asyncProducer.AsyncProducer).Successes():
-				logger.Info("Success produce message  ", zap.Any(msg.Topic, msg.Value))
+				logger.Module("mq").Info("Success produce message  ", zap.Any(msg.Topic, msg.Value))
 			case err := <-(*asyncProducer.AsyncProducer).Errors():
 				KafkaStdLogger.Println("message send error", zap.Error(err))
 				if errors.Is(err, sarama.ErrOutOfBrokers) || errors.Is(err, sarama.ErrNotConnected) {
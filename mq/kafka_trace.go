@@ -0,0 +1,70 @@
+package mq
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/phper95/pkg/trace"
+)
+
+// producerHeaderCarrier 读写生产者消息的 header
+type producerHeaderCarrier struct {
+	msg *sarama.ProducerMessage
+}
+
+func (c producerHeaderCarrier) Get(key string) string {
+	for _, header := range c.msg.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c producerHeaderCarrier) Set(key, value string) {
+	for i, header := range c.msg.Headers {
+		if string(header.Key) == key {
+			c.msg.Headers[i].Value = []byte(value)
+			return
+		}
+	}
+	c.msg.Headers = append(c.msg.Headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// consumerHeaderCarrier 读取消费者消息的 header，Set 不做任何处理
+type consumerHeaderCarrier struct {
+	msg *sarama.ConsumerMessage
+}
+
+func (c consumerHeaderCarrier) Get(key string) string {
+	for _, header := range c.msg.Headers {
+		if header != nil && string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+func (c consumerHeaderCarrier) Set(key, value string) {}
+
+// InjectTrace 开始一个发送消息的 span，并将链路信息写入消息 header，由调用方在发送完成后调用 span.End()；
+// SyncProducer、AsyncProducer 发送时不会自动写入，需要传递链路信息时在 Send 之前调用；
+// 消息 header 需要 kafka 0.11 及以上版本，默认配置为 V2_0_0_0
+func InjectTrace(msg *sarama.ProducerMessage, t *trace.Trace) *trace.Span {
+	if msg == nil || t == nil {
+		return nil
+	}
+
+	span := t.StartSpan("kafka send "+msg.Topic, nil)
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.destination", msg.Topic)
+
+	trace.Inject(producerHeaderCarrier{msg: msg}, t, span)
+	return span
+}
+
+// ExtractTrace 从消息 header 中读取上游的链路信息并创建 trace，没有链路信息时创建新的 trace
+func ExtractTrace(msg *sarama.ConsumerMessage) *trace.Trace {
+	if msg == nil {
+		return trace.New("")
+	}
+	return trace.Extract(consumerHeaderCarrier{msg: msg})
+}
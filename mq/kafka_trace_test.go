@@ -0,0 +1,86 @@
+package mq
+
+import (
+	"github.com/Shopify/sarama"
+	"github.com/phper95/pkg/logger"
+	"github.com/phper95/pkg/trace"
+	"testing"
+)
+
+func TestProducerHeaderCarrier(t *testing.T) {
+	msg := &sarama.ProducerMessage{Topic: "test"}
+	carrier := producerHeaderCarrier{msg: msg}
+
+	carrier.Set("k", "v1")
+	carrier.Set("k", "v2")
+	if carrier.Get("k") != "v2" || carrier.Get("none") != "" || len(msg.Headers) != 1 {
+		t.Fatalf("unexpected headers %v", msg.Headers)
+	}
+}
+
+func TestConsumerHeaderCarrier(t *testing.T) {
+	msg := &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{nil, {Key: []byte("k"), Value: []byte("v")}}}
+	carrier := consumerHeaderCarrier{msg: msg}
+
+	carrier.Set("k", "other")
+	if carrier.Get("k") != "v" || carrier.Get("none") != "" {
+		t.Fatalf("unexpected headers %v", msg.Headers)
+	}
+}
+
+// consumerMessage 将生产者消息的 header 转换为消费者收到的消息
+func consumerMessage(msg *sarama.ProducerMessage) *sarama.ConsumerMessage {
+	headers := make([]*sarama.RecordHeader, len(msg.Headers))
+	for i := range msg.Headers {
+		headers[i] = &msg.Headers[i]
+	}
+	return &sarama.ConsumerMessage{Topic: msg.Topic, Headers: headers}
+}
+
+func TestInjectExtractTrace(t *testing.T) {
+	up := trace.New("")
+	msg := &sarama.ProducerMessage{Topic: "order"}
+
+	span := InjectTrace(msg, up)
+	span.End()
+	if span.ParentSpanID != up.SpanID || span.Attributes["messaging.destination"] != "order" {
+		t.Fatalf("unexpected span %+v", span)
+	}
+
+	down := ExtractTrace(consumerMessage(msg))
+	if down.ID() != up.ID() || down.ParentSpanID != span.SpanID {
+		t.Fatalf("unexpected trace %+v", down)
+	}
+
+	if InjectTrace(nil, up) != nil || InjectTrace(msg, nil) != nil {
+		t.Fatal("expect nil span")
+	}
+	if fresh := ExtractTrace(nil); fresh.ID() == "" || fresh.ParentSpanID != "" {
+		t.Fatalf("unexpected trace %+v", fresh)
+	}
+}
+
+func TestMessageContext(t *testing.T) {
+	up := trace.New("order-123")
+	msg := &sarama.ProducerMessage{Topic: "order"}
+	InjectTrace(msg, up).End()
+
+	fields := logger.ContextFields(MessageContext(consumerMessage(msg)))
+	if len(fields) != 4 || fields[0].Key != logger.TraceIDKey || fields[0].String != "order-123" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	// 没有 trace.Header 时使用 traceparent 中的 trace-id
+	traceparent := trace.FormatTraceparent("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7")
+	only := &sarama.ConsumerMessage{Topic: "order", Headers: []*sarama.RecordHeader{
+		{Key: []byte(trace.HeaderTraceparent), Value: []byte(traceparent)},
+	}}
+	fields = logger.ContextFields(MessageContext(only))
+	if fields[0].Key != logger.TraceIDKey || fields[0].String != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+
+	if fields := logger.ContextFields(MessageContext(&sarama.ConsumerMessage{Topic: "order"})); len(fields) != 3 {
+		t.Fatalf("unexpected fields %v", fields)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/phper95/pkg/nosql
 
 go 1.16
 
-require go.mongodb.org/mongo-driver v1.9.1
+require (
+	github.com/phper95/pkg/errors v0.0.0-20230517145757-27be2fc31eea
+	go.mongodb.org/mongo-driver v1.9.1
+)
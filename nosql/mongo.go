@@ -2,8 +2,8 @@ package nosql
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"github.com/phper95/pkg/errors"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -115,6 +115,34 @@ func (client *MgClient) InsertManyTryBest(db string, table string, docs ...inter
 	return nil
 }
 
+// InsertManyTryBestMulti 与 InsertManyTryBest 相同，部分文档写入失败时返回 *errors.MultiError，
+// Index 为文档在 docs 中的下标，错误为 mongo.WriteError；其它错误原样返回
+// err := GetMongoClient(DefaultMongoClient).InsertManyTryBestMulti("db", "table", doc)
+// if multiErr, ok := err.(*errors.MultiError); ok {
+// for _, e := range multiErr.Errors() {
+// if e.Err.(mongo.WriteError).HasErrorCode(11000) {
+// TO DO ...
+// }
+// }
+// }
+func (client *MgClient) InsertManyTryBestMulti(db string, table string, docs ...interface{}) error {
+	return writeErrors(client.InsertManyTryBest(db, table, docs...))
+}
+
+// writeErrors 把 mongo.BulkWriteException 中每个文档的错误转换为 errors.MultiError
+func writeErrors(err error) error {
+	var bwe mongo.BulkWriteException
+	if !errors.As(err, &bwe) || bwe.WriteConcernError != nil || len(bwe.WriteErrors) == 0 {
+		return err
+	}
+
+	multiErr := errors.NewMultiError()
+	for _, we := range bwe.WriteErrors {
+		multiErr.Add(we.Index, we.WriteError)
+	}
+	return multiErr
+}
+
 // Upsert doc是bson格式
 func (client *MgClient) Upsert(db string, table string, filter bson.D, doc interface{}) error {
 	collection := client.Database(db).Collection(table)
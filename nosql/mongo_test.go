@@ -0,0 +1,30 @@
+package nosql
+
+import (
+	"github.com/phper95/pkg/errors"
+	"go.mongodb.org/mongo-driver/mongo"
+	"testing"
+)
+
+func TestWriteErrors(t *testing.T) {
+	bwe := mongo.BulkWriteException{WriteErrors: []mongo.BulkWriteError{
+		{WriteError: mongo.WriteError{Index: 1, Code: 11000, Message: "duplicate key"}},
+		{WriteError: mongo.WriteError{Index: 3, Code: 11000, Message: "duplicate key"}},
+	}}
+	if !mongo.IsDuplicateKeyError(bwe) {
+		t.Fatal("expect duplicate key error")
+	}
+
+	multiErr, ok := writeErrors(bwe).(*errors.MultiError)
+	if !ok || multiErr.Len() != 2 || multiErr.Errors()[1].Index != 3 {
+		t.Fatalf("unexpected error %v", multiErr)
+	}
+	if e := multiErr.Errors()[0].Err.(mongo.WriteError); !e.HasErrorCode(11000) {
+		t.Fatalf("unexpected write error %v", e)
+	}
+
+	other := errors.New("timeout")
+	if writeErrors(other) != other || writeErrors(nil) != nil {
+		t.Fatal("expect original error")
+	}
+}
@@ -176,15 +176,8 @@ func (p *Pool) executeJob(task Task, timeout time.Duration) {
 			defer cancel()
 		}
 
-		//捕获异常堆栈
-		defer func() {
-			e := recover()
-			if e != nil {
-				s := errors.Stack(2)
-				log.Fatalf("routinepool[%v] Panic: %v\nTraceback\r:%s",
-					p.Name, e, string(s))
-			}
-		}()
+		//捕获异常堆栈，通知 errors.RegisterReporter 注册的 Reporter，任务 panic 不会导致进程退出
+		defer errors.Recover(errors.WithRecoverFields("routine_pool", p.Name, "task", task.GetTaskName()))
 
 		start := time.Now()
 		task.Execute()
@@ -13,7 +13,7 @@ func TestRoutinePoolPanic(t *testing.T) {
 
 	panicFunc := func() {
 		var err error
-		err.Error()
+		_ = err.Error()
 	}
 	GetPool(PoolNameDefault).Put(panicFunc)
 	success := false
@@ -0,0 +1,194 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"github.com/pkg/errors"
+	"time"
+)
+
+const (
+	// AlgHMACSHA256 默认算法，authorization 中不声明 alg 时使用
+	AlgHMACSHA256 = "hmac-sha256"
+	// AlgRSAPSSSHA256 RSA-PSS，摘要算法 SHA256
+	AlgRSAPSSSHA256 = "rsa-pss-sha256"
+	// AlgECDSAP256SHA256 ECDSA P-256 曲线，摘要算法 SHA256
+	AlgECDSAP256SHA256 = "ecdsa-p256-sha256"
+	// AlgEd25519 Ed25519
+	AlgEd25519 = "ed25519"
+)
+
+var algorithms = map[string]bool{
+	AlgHMACSHA256:      true,
+	AlgRSAPSSSHA256:    true,
+	AlgECDSAP256SHA256: true,
+	AlgEd25519:         true,
+}
+
+var pssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+
+// NewWithPrivateKey 使用 PEM 格式的私钥创建 Signature，算法由私钥类型决定：
+// RSA 使用 RSA-PSS/SHA256，ECDSA 只支持 P-256 曲线，Ed25519；
+// 签名串与 HMAC 版本相同，authorization 中会声明 alg
+func NewWithPrivateKey(key string, privateKeyPEM []byte, ttl time.Duration, options ...Option) (RequestSignature, error) {
+	privateKey, err := ParsePrivateKeyPEM(privateKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	alg, publicKey, err := keyAlgorithm(privateKey)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSignature(key, ttl, Secret{PublicKey: publicKey}, options...)
+	s.algorithm = alg
+	s.signer = func(plain []byte) (string, error) {
+		return asymmetricSign(alg, privateKey, plain)
+	}
+	return s, nil
+}
+
+// NewWithPublicKey 使用 PEM 格式的公钥创建只能用于验证的 Signature，Generate 会返回错误
+func NewWithPublicKey(key string, publicKeyPEM []byte, ttl time.Duration, options ...Option) (RequestSignature, error) {
+	publicKey, err := ParsePublicKeyPEM(publicKeyPEM)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newSignature(key, ttl, Secret{PublicKey: publicKey}, options...)
+	s.signer = func(plain []byte) (string, error) {
+		return "", errors.New("private key required")
+	}
+	return s, nil
+}
+
+// ParsePrivateKeyPEM 解析 PKCS8、PKCS1(RSA) 或 SEC1(EC) 格式的私钥
+func ParsePrivateKeyPEM(privateKeyPEM []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(privateKeyPEM)
+	if block == nil {
+		return nil, errors.New("invalid private key pem")
+	}
+
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		signer, ok := key.(crypto.Signer)
+		if !ok {
+			return nil, errors.Errorf("unsupported private key type %T", key)
+		}
+		return signer, nil
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported private key pem")
+}
+
+// ParsePublicKeyPEM 解析 PKIX 或 PKCS1(RSA) 格式的公钥，可用于 Secret.PublicKey
+func ParsePublicKeyPEM(publicKeyPEM []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(publicKeyPEM)
+	if block == nil {
+		return nil, errors.New("invalid public key pem")
+	}
+
+	if key, err := x509.ParsePKIXPublicKey(block.Bytes); err == nil {
+		if _, _, err = keyAlgorithm(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if key, err := x509.ParsePKCS1PublicKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	return nil, errors.New("unsupported public key pem")
+}
+
+// keyAlgorithm 根据私钥或公钥类型返回签名算法和公钥
+func keyAlgorithm(key interface{}) (string, crypto.PublicKey, error) {
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return AlgRSAPSSSHA256, pub, nil
+	case *ecdsa.PublicKey:
+		if pub.Curve != elliptic.P256() {
+			return "", nil, errors.New("only P-256 curve is supported")
+		}
+		return AlgECDSAP256SHA256, pub, nil
+	case ed25519.PublicKey:
+		return AlgEd25519, pub, nil
+	default:
+		return "", nil, errors.Errorf("unsupported key type %T", key)
+	}
+}
+
+func asymmetricSign(alg string, privateKey crypto.Signer, plain []byte) (string, error) {
+	var (
+		sig []byte
+		err error
+	)
+
+	switch alg {
+	case AlgRSAPSSSHA256:
+		hashed := sha256.Sum256(plain)
+		sig, err = rsa.SignPSS(rand.Reader, privateKey.(*rsa.PrivateKey), crypto.SHA256, hashed[:], pssOptions)
+	case AlgECDSAP256SHA256:
+		hashed := sha256.Sum256(plain)
+		sig, err = ecdsa.SignASN1(rand.Reader, privateKey.(*ecdsa.PrivateKey), hashed[:])
+	case AlgEd25519:
+		sig = ed25519.Sign(privateKey.(ed25519.PrivateKey), plain)
+	default:
+		err = errors.Errorf("unsupported algorithm %s", alg)
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "sign with %s error", alg)
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+// verifyDigest 使用 secret 按 alg 验证签名，secret 中没有对应算法的密钥时返回 false
+func verifyDigest(alg string, secret Secret, plain []byte, digest string) bool {
+	if alg == "" || alg == AlgHMACSHA256 {
+		return secret.Value != "" && hmac.Equal([]byte(digest), []byte(hmacSign(secret.Value, plain)))
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return false
+	}
+
+	switch alg {
+	case AlgRSAPSSSHA256:
+		pub, ok := secret.PublicKey.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		hashed := sha256.Sum256(plain)
+		return rsa.VerifyPSS(pub, crypto.SHA256, hashed[:], sig, pssOptions) == nil
+	case AlgECDSAP256SHA256:
+		pub, ok := secret.PublicKey.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() {
+			return false
+		}
+		hashed := sha256.Sum256(plain)
+		return ecdsa.VerifyASN1(pub, hashed[:], sig)
+	case AlgEd25519:
+		pub, ok := secret.PublicKey.(ed25519.PublicKey)
+		return ok && ed25519.Verify(pub, plain, sig)
+	default:
+		return false
+	}
+}
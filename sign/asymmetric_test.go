@@ -0,0 +1,67 @@
+package sign
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestNewWithPrivateKey(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	params := url.Values{}
+	params.Add("a", "a1")
+
+	for alg, privateKey := range map[string]crypto.Signer{
+		AlgRSAPSSSHA256:    rsaKey,
+		AlgECDSAP256SHA256: ecKey,
+		AlgEd25519:         edKey,
+	} {
+		der, err := x509.MarshalPKCS8PrivateKey(privateKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		privatePEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+
+		der, _ = x509.MarshalPKIXPublicKey(privateKey.Public())
+		publicPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+
+		signer, err := NewWithPrivateKey(key, privatePEM, ttl)
+		if err != nil {
+			t.Fatal(alg, err)
+		}
+		authorization, date, err := signer.Generate("/echo", "POST", params)
+		if err != nil {
+			t.Fatal(alg, err)
+		}
+		if !strings.Contains(authorization, "alg="+alg) {
+			t.Fatalf("authorization %s should declare %s", authorization, alg)
+		}
+
+		verifier, err := NewWithPublicKey(key, publicPEM, ttl)
+		if err != nil {
+			t.Fatal(alg, err)
+		}
+		if ok, err := verifier.Verify(authorization, date, "/echo", "POST", params); err != nil || !ok {
+			t.Fatalf("%s verify failed: %v", alg, err)
+		}
+		if ok, _ := verifier.Verify(authorization, date, "/echo", "GET", params); ok {
+			t.Fatalf("%s tampered method should not pass", alg)
+		}
+
+		// HMAC 密钥不能验证非对称签名
+		if ok, _ := New(key, secret, ttl).Verify(authorization, date, "/echo", "POST", params); ok {
+			t.Fatalf("%s signature should not pass hmac verify", alg)
+		}
+	}
+}
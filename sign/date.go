@@ -0,0 +1,102 @@
+package sign
+
+import (
+	"github.com/phper95/pkg/timeutil"
+	"github.com/pkg/errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DateFormatCST "2006-01-02 15:04:05" 中国标准时间，默认格式，兼容旧版本调用方
+	DateFormatCST DateFormat = iota
+	// DateFormatRFC3339 UTC 时间 "2006-01-02T15:04:05Z"，与时区无关
+	DateFormatRFC3339
+	// DateFormatUnix unix 时间戳(秒)，与时区无关
+	DateFormatUnix
+)
+
+// DateFormat 签名时间的格式，验证时自动识别三种格式
+type DateFormat int
+
+var (
+	// now 当前时间，测试时替换
+	now = time.Now
+
+	// cstLocation 中国标准时间，没有夏令时，与 timeutil 中的 Asia/Shanghai 一致
+	cstLocation = time.FixedZone("CST", 8*3600)
+)
+
+// WithDateFormat 设置签名时间的格式，默认 DateFormatCST
+func WithDateFormat(format DateFormat) Option {
+	return func(opt *option) {
+		opt.dateFormat = format
+	}
+}
+
+// WithClockSkew 设置允许的时钟偏差：签名时间最多比当前时间早 ttl+skew，最多晚 skew；
+// 不设置时签名时间前后各允许偏差 ttl
+func WithClockSkew(skew time.Duration) Option {
+	return func(opt *option) {
+		opt.clockSkew = skew
+		opt.clockSkewSet = true
+	}
+}
+
+func formatDate(format DateFormat, t time.Time) string {
+	switch format {
+	case DateFormatRFC3339:
+		return t.UTC().Format(time.RFC3339)
+	case DateFormatUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	default:
+		return t.In(cstLocation).Format(timeutil.CSTLayout)
+	}
+}
+
+// parseDate 按 unix 时间戳、RFC3339、CST 的顺序识别签名时间
+func parseDate(date string) (time.Time, error) {
+	if unix, err := strconv.ParseInt(date, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+
+	if strings.Contains(date, "T") {
+		ts, err := time.Parse(time.RFC3339, date)
+		if err != nil {
+			return time.Time{}, errors.New("date must follow RFC3339 '2006-01-02T15:04:05Z'")
+		}
+		return ts, nil
+	}
+
+	ts, err := timeutil.ParseCSTInLocation(date)
+	if err != nil {
+		return time.Time{}, errors.New("date must follow '2006-01-02 15:04:05', RFC3339 or unix timestamp")
+	}
+	return ts, nil
+}
+
+// checkDate 校验签名时间是否在允许的窗口内
+func checkDate(ts time.Time, ttl time.Duration, opt *option) error {
+	past, future := ttl, ttl
+	if opt.clockSkewSet {
+		past, future = ttl+opt.clockSkew, opt.clockSkew
+	}
+
+	current := now()
+	if current.Sub(ts) > past {
+		return errors.Errorf("date exceeds limit %v", ttl)
+	}
+	if ts.Sub(current) > future {
+		return errors.Errorf("date is in the future beyond clock skew %v", future)
+	}
+	return nil
+}
+
+// dateWindow 签名时间允许的窗口长度，nonce 需要保留同样长的时间
+func dateWindow(ttl time.Duration, opt *option) time.Duration {
+	if opt.clockSkewSet {
+		return ttl + 2*opt.clockSkew
+	}
+	return 2 * ttl
+}
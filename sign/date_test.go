@@ -0,0 +1,59 @@
+package sign
+
+import (
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestDateFormat(t *testing.T) {
+	params := url.Values{}
+	params.Add("a", "a1")
+
+	verifier := New(key, secret, ttl)
+	for _, format := range []DateFormat{DateFormatCST, DateFormatRFC3339, DateFormatUnix} {
+		authorization, date, err := New(key, secret, ttl, WithDateFormat(format)).Generate("/echo", "GET", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log("date:", date)
+
+		if ok, err := verifier.Verify(authorization, date, "/echo", "GET", params); err != nil || !ok {
+			t.Fatalf("format %d verify failed: %v", format, err)
+		}
+	}
+}
+
+func TestClockSkew(t *testing.T) {
+	defer func() { now = time.Now }()
+
+	params := url.Values{}
+	params.Add("a", "a1")
+	signer := New(key, secret, ttl, WithDateFormat(DateFormatRFC3339))
+	verifier := New(key, secret, ttl, WithClockSkew(30*time.Second))
+
+	cases := []struct {
+		offset time.Duration // 签名方时钟相对验证方的偏差
+		ok     bool
+	}{
+		{offset: 0, ok: true},
+		{offset: 20 * time.Second, ok: true},
+		{offset: time.Minute, ok: false},
+		{offset: -ttl - 20*time.Second, ok: true},
+		{offset: -ttl - time.Minute, ok: false},
+	}
+
+	for _, c := range cases {
+		now = func() time.Time { return time.Now().Add(c.offset) }
+		authorization, date, err := signer.Generate("/echo", "GET", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		now = time.Now
+		ok, err := verifier.Verify(authorization, date, "/echo", "GET", params)
+		if ok != c.ok {
+			t.Fatalf("offset %v expect %v, got %v %v", c.offset, c.ok, ok, err)
+		}
+	}
+}
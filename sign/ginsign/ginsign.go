@@ -0,0 +1,31 @@
+// Package ginsign sign 验签中间件的 gin 适配，单独作为 module 避免 sign 依赖 gin
+package ginsign
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/phper95/pkg/sign"
+	"net/http"
+)
+
+// ContextKey 验签通过后，调用方的 key 会通过 gin.Context.Set 存入该 key
+const ContextKey = "sign_key"
+
+// Middleware gin 验签中间件，与 sign.Middleware 行为一致；
+// 验证通过后可以通过 c.GetString(ContextKey) 或 sign.KeyFromContext(c.Request.Context()) 获取调用方的 key
+func Middleware(verifier sign.Verifier, options ...sign.MiddlewareOption) gin.HandlerFunc {
+	verify := sign.Middleware(verifier, options...)
+
+	return func(c *gin.Context) {
+		passed := false
+		verify(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			passed = true
+			c.Request = r
+			c.Set(ContextKey, sign.KeyFromContext(r.Context()))
+			c.Next()
+		})).ServeHTTP(c.Writer, c.Request)
+
+		if !passed {
+			c.Abort()
+		}
+	}
+}
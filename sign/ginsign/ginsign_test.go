@@ -0,0 +1,54 @@
+package ginsign
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/phper95/pkg/sign"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const (
+	key    = "AK100523687952"
+	secret = "W1WTYvJpfeH1YpUjTpeFbEx^DnpQ&35L"
+	ttl    = time.Minute * 3
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := sign.NewSecretRegistry()
+	registry.Add(key, sign.Secret{Version: "v1", Value: secret})
+
+	engine := gin.New()
+	engine.Use(Middleware(sign.NewVerifier(registry, ttl)))
+	engine.POST("/echo", func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(ContextKey)+" "+sign.KeyFromContext(c.Request.Context()))
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/echo?a=a1", nil)
+	authorization, date, err := sign.New(key, secret, ttl).GenerateRequest(&sign.Request{
+		Path:   "/echo",
+		Method: http.MethodPost,
+		Params: r.URL.Query(),
+		Header: r.Header,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set(sign.HeaderAuthorization, authorization)
+	r.Header.Set(sign.HeaderDate, date)
+
+	w := httptest.NewRecorder()
+	engine.ServeHTTP(w, r)
+	if w.Code != http.StatusOK || w.Body.String() != key+" "+key {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	engine.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
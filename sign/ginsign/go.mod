@@ -0,0 +1,12 @@
+module github.com/phper95/pkg/sign/ginsign
+
+go 1.16
+
+require (
+	github.com/gin-gonic/gin v1.7.7
+	github.com/phper95/pkg/sign v0.0.0-20230517145757-27be2fc31eea
+	github.com/phper95/pkg/timeutil v0.0.0-20230517145757-27be2fc31eea // indirect
+)
+
+// 已发布的 sign 依赖的 timeutil 版本声明的 module 路径为 gitee.com/phper95/pkg/timeutil，无法使用
+exclude github.com/phper95/pkg/timeutil v0.0.0-20220722023345-0b3333d26940
@@ -3,6 +3,6 @@ module github.com/phper95/pkg/sign
 go 1.16
 
 require (
-	github.com/phper95/pkg/timeutil v0.0.0-20220722023345-0b3333d26940
+	github.com/phper95/pkg/timeutil v0.0.0-20230517145757-27be2fc31eea
 	github.com/pkg/errors v0.9.1
 )
@@ -0,0 +1,198 @@
+package sign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const (
+	// HeaderAuthorization 签名所在的 header
+	HeaderAuthorization = "Authorization"
+	// HeaderDate 签名时间所在的 header
+	HeaderDate = "Date"
+
+	// DefaultMaxBodyBytes 中间件读取 body 的默认上限 10M
+	DefaultMaxBodyBytes = 10 << 20
+)
+
+type contextKey struct{}
+
+type stdLogger interface {
+	Print(v ...interface{})
+	Printf(format string, v ...interface{})
+	Println(v ...interface{})
+}
+
+// SignStdLogger 默认的验签失败处理打印服务端错误详情
+var SignStdLogger stdLogger
+
+func init() {
+	SignStdLogger = log.New(os.Stdout, "[Sign] ", log.LstdFlags|log.Lshortfile)
+}
+
+// UnauthorizedBody 验签失败时返回的 json body
+type UnauthorizedBody struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// UnauthorizedHandler 验签失败时的处理，可以通过 IsBackendError 区分服务端依赖的错误
+type UnauthorizedHandler func(w http.ResponseWriter, r *http.Request, err error)
+
+// MiddlewareOption 自定义设置验签中间件
+type MiddlewareOption func(*middlewareOption)
+
+type middlewareOption struct {
+	maxBodyBytes int64
+	unauthorized UnauthorizedHandler
+}
+
+// WithMaxBodyBytes 设置对 body 签名时最多读取的 body 大小
+func WithMaxBodyBytes(n int64) MiddlewareOption {
+	return func(opt *middlewareOption) {
+		opt.maxBodyBytes = n
+	}
+}
+
+// WithUnauthorizedHandler 自定义验签失败时的返回，默认返回 401 和 UnauthorizedBody，
+// 服务端依赖的错误返回 500，错误详情只打印到 SignStdLogger，不返回给调用方
+func WithUnauthorizedHandler(handler UnauthorizedHandler) MiddlewareOption {
+	return func(opt *middlewareOption) {
+		opt.unauthorized = handler
+	}
+}
+
+func newMiddlewareOption(options ...MiddlewareOption) *middlewareOption {
+	opt := &middlewareOption{
+		maxBodyBytes: DefaultMaxBodyBytes,
+		unauthorized: writeUnauthorized,
+	}
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	return opt
+}
+
+// Middleware net/http 验签中间件，验证通过后可以通过 ResultFromContext 获取调用方的 key
+func Middleware(verifier Verifier, options ...MiddlewareOption) func(http.Handler) http.Handler {
+	opt := newMiddlewareOption(options...)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			result, err := VerifyHTTPRequest(verifier, r, opt.maxBodyBytes)
+			if err != nil {
+				opt.unauthorized(w, r, err)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), result)))
+		})
+	}
+}
+
+// NewContext 将验签结果存入 context
+func NewContext(ctx context.Context, result *VerifyResult) context.Context {
+	return context.WithValue(ctx, contextKey{}, result)
+}
+
+// ResultFromContext 获取中间件存入的验签结果
+func ResultFromContext(ctx context.Context) (*VerifyResult, bool) {
+	result, ok := ctx.Value(contextKey{}).(*VerifyResult)
+	return result, ok
+}
+
+// KeyFromContext 获取验签通过的调用方 key
+func KeyFromContext(ctx context.Context) string {
+	if result, ok := ResultFromContext(ctx); ok {
+		return result.Key
+	}
+	return ""
+}
+
+// VerifyHTTPRequest 从请求中取出 Authorization 和 Date 验签；
+// 签名参数为 query 参数，form 请求还包含 body 中的参数；
+// 读取的 body 会被还原，后续 handler 可以再次读取
+func VerifyHTTPRequest(verifier Verifier, r *http.Request, maxBodyBytes int64) (*VerifyResult, error) {
+	authorization := r.Header.Get(HeaderAuthorization)
+	if authorization == "" {
+		return nil, errors.New("authorization required")
+	}
+
+	auth, err := parseAuthorization(authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	params := r.URL.Query()
+	isForm := strings.HasPrefix(r.Header.Get("Content-Type"), "application/x-www-form-urlencoded")
+
+	var body []byte
+	if (auth.bodyDigest != "" || isForm) && r.Body != nil {
+		if body, err = readBody(r, maxBodyBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if isForm && len(body) > 0 {
+		form, err := url.ParseQuery(string(body))
+		if err != nil {
+			return nil, errors.Wrap(err, "parse form body error")
+		}
+		for key, values := range form {
+			for _, value := range values {
+				params.Add(key, value)
+			}
+		}
+	}
+
+	return verifier.VerifyRequest(authorization, r.Header.Get(HeaderDate), &Request{
+		Path:   r.URL.Path,
+		Method: r.Method,
+		Params: params,
+		Header: r.Header,
+		Body:   body,
+	})
+}
+
+// readBody 读取 body 并还原
+func readBody(r *http.Request, maxBodyBytes int64) ([]byte, error) {
+	if maxBodyBytes <= 0 {
+		maxBodyBytes = DefaultMaxBodyBytes
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodyBytes+1))
+	r.Body.Close()
+	if err != nil {
+		return nil, errors.Wrap(err, "read body error")
+	}
+	if int64(len(body)) > maxBodyBytes {
+		return nil, errors.Errorf("body exceeds limit %d bytes", maxBodyBytes)
+	}
+
+	r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+func writeUnauthorized(w http.ResponseWriter, r *http.Request, err error) {
+	body := &UnauthorizedBody{Code: http.StatusUnauthorized, Message: "signature verification failed"}
+	if IsBackendError(err) {
+		SignStdLogger.Printf("verify signature of %s %s error: %v", r.Method, r.URL.Path, err)
+		body = &UnauthorizedBody{Code: http.StatusInternalServerError, Message: "signature verification unavailable"}
+	}
+
+	raw, _ := json.Marshal(body)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(body.Code)
+	w.Write(raw)
+}
@@ -0,0 +1,93 @@
+package sign
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newSignedRequest(t *testing.T, signature RequestSignature, body []byte) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/echo?a=a1", bytes.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+
+	authorization, date, err := signature.GenerateRequest(&Request{
+		Path:   "/echo",
+		Method: http.MethodPost,
+		Params: r.URL.Query(),
+		Header: r.Header,
+		Body:   body,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.Header.Set(HeaderAuthorization, authorization)
+	r.Header.Set(HeaderDate, date)
+	return r
+}
+
+func TestMiddleware(t *testing.T) {
+	registry := NewSecretRegistry()
+	registry.Add(key, Secret{Version: "v1", Value: secret})
+
+	handler := Middleware(NewVerifier(registry, ttl, WithBodyDigest()))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		w.Write([]byte(KeyFromContext(r.Context()) + " " + string(body)))
+	}))
+
+	body := []byte(`{"amount":100}`)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(t, New(key, secret, ttl, WithBodyDigest()), body))
+	if w.Code != http.StatusOK || w.Body.String() != key+" "+string(body) {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// 篡改 body
+	r := newSignedRequest(t, New(key, secret, ttl, WithBodyDigest()), body)
+	r.Body = ioutil.NopCloser(bytes.NewReader([]byte(`{"amount":1}`)))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	reply := new(UnauthorizedBody)
+	if err := json.Unmarshal(w.Body.Bytes(), reply); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusUnauthorized || reply.Code != http.StatusUnauthorized {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
+
+// failingProvider 模拟查询密钥时 redis 不可用
+type failingProvider struct{}
+
+func (failingProvider) Secrets(key string) ([]Secret, error) {
+	return nil, errors.New("dial tcp 10.0.0.1:6379: connection refused")
+}
+
+func TestMiddlewareBackendError(t *testing.T) {
+	SignStdLogger = log.New(ioutil.Discard, "", 0)
+	defer func() {
+		SignStdLogger = log.New(os.Stdout, "[Sign] ", log.LstdFlags|log.Lshortfile)
+	}()
+
+	handler := Middleware(NewVerifier(failingProvider{}, ttl))(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, newSignedRequest(t, New(key, secret, ttl), nil))
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "10.0.0.1") {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+
+	// 签名错误只返回固定的信息
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/echo", nil))
+	if w.Code != http.StatusUnauthorized || strings.Contains(w.Body.String(), "authorization required") {
+		t.Fatalf("unexpected response %d %s", w.Code, w.Body.String())
+	}
+}
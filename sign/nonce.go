@@ -0,0 +1,114 @@
+package sign
+
+import (
+	"container/list"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultNonceCapacity 内存 nonce 存储默认最多保留的 nonce 数
+	DefaultNonceCapacity = 100000
+
+	// DefaultNoncePrefix redis nonce 存储默认的 key 前缀
+	DefaultNoncePrefix = "sign:nonce:"
+)
+
+// NonceStore 记录已使用的 nonce，用于防重放
+type NonceStore interface {
+	// Add 记录 nonce，nonce 在 ttl 内已被使用过时返回 false
+	Add(key, nonce string, ttl time.Duration) (ok bool, err error)
+}
+
+// newNonce 生成随机 nonce
+func newNonce() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := io.ReadFull(rand.Reader, buf); err != nil {
+		return "", errors.Wrap(err, "generate nonce error")
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+type nonceEntry struct {
+	id     string
+	expire time.Time
+}
+
+type memoryNonceStore struct {
+	mux      sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	lru      *list.List
+}
+
+// NewMemoryNonceStore 进程内的 LRU nonce 存储，只适用于单实例部署；
+// 超过 capacity 时淘汰最早的 nonce，capacity 应大于窗口期内的请求数
+func NewMemoryNonceStore(capacity int) NonceStore {
+	if capacity <= 0 {
+		capacity = DefaultNonceCapacity
+	}
+	return &memoryNonceStore{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		lru:      list.New(),
+	}
+}
+
+func (s *memoryNonceStore) Add(key, nonce string, ttl time.Duration) (bool, error) {
+	id := key + ":" + nonce
+	now := time.Now()
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if elem, ok := s.entries[id]; ok {
+		if elem.Value.(*nonceEntry).expire.After(now) {
+			return false, nil
+		}
+		s.lru.Remove(elem)
+		delete(s.entries, id)
+	}
+
+	// 淘汰过期和超出容量的 nonce
+	for elem := s.lru.Back(); elem != nil; elem = s.lru.Back() {
+		entry := elem.Value.(*nonceEntry)
+		if s.lru.Len() < s.capacity && entry.expire.After(now) {
+			break
+		}
+		s.lru.Remove(elem)
+		delete(s.entries, entry.id)
+	}
+
+	s.entries[id] = s.lru.PushFront(&nonceEntry{id: id, expire: now.Add(ttl)})
+	return true, nil
+}
+
+// RedisSetNX redis SETNX 命令，与 cache.NXSetter 一致，*cache.Redis 实现了该接口
+type RedisSetNX interface {
+	SetNX(key string, value interface{}, ttl time.Duration) (bool, error)
+}
+
+type redisNonceStore struct {
+	client RedisSetNX
+	prefix string
+}
+
+// NewRedisNonceStore 基于 redis SETNX + TTL 的 nonce 存储，适用于多实例部署
+func NewRedisNonceStore(client RedisSetNX, prefix string) NonceStore {
+	if prefix == "" {
+		prefix = DefaultNoncePrefix
+	}
+	return &redisNonceStore{client: client, prefix: prefix}
+}
+
+func (s *redisNonceStore) Add(key, nonce string, ttl time.Duration) (bool, error) {
+	ok, err := s.client.SetNX(s.prefix+key+":"+nonce, 1, ttl)
+	if err != nil {
+		return false, errors.Wrap(err, "store nonce error")
+	}
+	return ok, nil
+}
@@ -5,19 +5,23 @@ import (
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"fmt"
-	"github.com/phper95/pkg/timeutil"
 	"github.com/pkg/errors"
 	"net/http"
 	"net/url"
+	"sort"
 	"strings"
 	"time"
 )
 
-var _ Signature = (*signature)(nil)
+var _ RequestSignature = (*signature)(nil)
 
 const (
 	delimiter = "|"
+
+	// BodyDigestSHA256 body 摘要算法
+	BodyDigestSHA256 = "sha256"
 )
 
 // 合法的 Methods
@@ -42,122 +46,320 @@ type Signature interface {
 	Verify(authorization, date string, path string, method string, params url.Values) (ok bool, err error)
 }
 
+// RequestSignature 在 Signature 的基础上支持对 body 摘要和 header 签名，New 等方法返回的签名都实现了该接口
+type RequestSignature interface {
+	Signature
+
+	// GenerateRequest 生成签名，按 WithBodyDigest 和 WithSignedHeaders 同时对 body 摘要和 header 签名
+	GenerateRequest(req *Request) (authorization, date string, err error)
+
+	// VerifyRequest 验证签名，authorization 中声明的 body 摘要和 header 会一起校验
+	VerifyRequest(authorization, date string, req *Request) (ok bool, err error)
+}
+
+// Request 待签名的请求
+type Request struct {
+	Path   string      // 请求的路径 (不附带 querystring)
+	Method string      // 请求方式
+	Params url.Values  // 请求参数
+	Header http.Header // 请求 Header，WithSignedHeaders 指定的 header 从这里取值
+	Body   []byte      // 原始请求 Body，WithBodyDigest 时对其 sha256 摘要签名
+}
+
+// Option 自定义签名方式
+type Option func(*option)
+
+type option struct {
+	bodyDigest    bool
+	signedHeaders []string
+	nonce         bool
+	nonceStore    NonceStore
+	dateFormat    DateFormat
+	clockSkew     time.Duration
+	clockSkewSet  bool
+}
+
+// WithBodyDigest 对原始 body 的 sha256 摘要签名；验证时要求签名包含 body 摘要
+func WithBodyDigest() Option {
+	return func(opt *option) {
+		opt.bodyDigest = true
+	}
+}
+
+// WithSignedHeaders 对指定的 header 签名；验证时要求签名至少包含这些 header
+func WithSignedHeaders(headers ...string) Option {
+	return func(opt *option) {
+		opt.signedHeaders = append(opt.signedHeaders, headers...)
+	}
+}
+
+// WithNonce 签名时附带随机 nonce
+func WithNonce() Option {
+	return func(opt *option) {
+		opt.nonce = true
+	}
+}
+
+// WithNonceStore 验证时要求签名附带 nonce，并拒绝窗口期内重复使用的 nonce；同时开启 WithNonce
+func WithNonceStore(store NonceStore) Option {
+	return func(opt *option) {
+		opt.nonce = true
+		opt.nonceStore = store
+	}
+}
+
 type signature struct {
-	key    string
-	secret string
-	ttl    time.Duration
+	key       string
+	ttl       time.Duration
+	opt       *option
+	algorithm string // 为空时使用 AlgHMACSHA256，且不在 authorization 中声明
+	signer    func(plain []byte) (string, error)
+	verifier  *verifier
 }
 
-func New(key, secret string, ttl time.Duration) Signature {
+func New(key, secret string, ttl time.Duration, options ...Option) RequestSignature {
+	s := newSignature(key, ttl, Secret{Value: secret}, options...)
+	s.signer = func(plain []byte) (string, error) {
+		return hmacSign(secret, plain), nil
+	}
+	return s
+}
+
+func newSignature(key string, ttl time.Duration, secret Secret, options ...Option) *signature {
+	opt := new(option)
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	opt.signedHeaders = canonicalHeaderNames(opt.signedHeaders)
+
 	return &signature{
-		key:    key,
-		secret: secret,
-		ttl:    ttl,
+		key:      key,
+		ttl:      ttl,
+		opt:      opt,
+		verifier: newVerifier(singleSecret{key: key, secret: secret}, ttl, opt),
 	}
 }
 
 // Generate
 // path 请求的路径 (不附带 querystring)
 func (s *signature) Generate(path string, method string, params url.Values) (authorization, date string, err error) {
-	if path == "" {
-		err = errors.New("path required")
+	return s.GenerateRequest(&Request{Path: path, Method: method, Params: params})
+}
+
+func (s *signature) GenerateRequest(req *Request) (authorization, date string, err error) {
+	if err = checkRequest(req); err != nil {
 		return
 	}
 
-	if method == "" {
-		err = errors.New("method required")
+	// Date
+	date = formatDate(s.opt.dateFormat, now())
+
+	auth := &authorizationHeader{
+		key:       s.key,
+		algorithm: s.algorithm,
+		headers:   s.opt.signedHeaders,
+	}
+	if s.opt.bodyDigest {
+		auth.bodyDigest = BodyDigestSHA256
+	}
+	if s.opt.nonce {
+		if auth.nonce, err = newNonce(); err != nil {
+			return
+		}
+	}
+
+	plain, err := canonicalString(req, date, auth)
+	if err != nil {
 		return
 	}
 
-	methodName := strings.ToUpper(method)
-	if !methods[methodName] {
-		err = errors.New("method param error")
+	if auth.digest, err = s.signer(plain); err != nil {
 		return
 	}
+	authorization = auth.String()
+	return
+}
 
-	// Date
-	date = timeutil.CSTLayoutString()
+func (s *signature) Verify(authorization, date string, path string, method string, params url.Values) (ok bool, err error) {
+	return s.VerifyRequest(authorization, date, &Request{Path: path, Method: method, Params: params})
+}
+
+func (s *signature) VerifyRequest(authorization, date string, req *Request) (ok bool, err error) {
+	if _, err = s.verifier.VerifyRequest(authorization, date, req); err != nil {
+		// 兼容原有行为：key 不匹配或签名不一致时只返回 false
+		if err == ErrUnknownKey || err == ErrSignatureMismatch {
+			err = nil
+		}
+		return
+	}
+	return true, nil
+}
 
+// hmacSign 对数据进行 hmac sha256 加密，并进行 base64 encode
+func hmacSign(secret string, plain []byte) string {
+	hash := hmac.New(sha256.New, []byte(secret))
+	hash.Write(plain)
+	return base64.StdEncoding.EncodeToString(hash.Sum(nil))
+}
+
+func checkRequest(req *Request) error {
+	if req == nil {
+		return errors.New("request required")
+	}
+
+	if req.Path == "" {
+		return errors.New("path required")
+	}
+
+	if req.Method == "" {
+		return errors.New("method required")
+	}
+
+	if !methods[strings.ToUpper(req.Method)] {
+		return errors.New("method param error")
+	}
+	return nil
+}
+
+// canonicalString 加密字符串规则：
+// path|METHOD|sorted params|date[|name:value\nname:value][|hex(sha256(body))][|nonce]
+func canonicalString(req *Request, date string, auth *authorizationHeader) ([]byte, error) {
 	// Encode() 方法中自带 sorted by key
-	sortParamsEncode, err := url.QueryUnescape(params.Encode())
+	sortParamsEncode, err := url.QueryUnescape(req.Params.Encode())
 	if err != nil {
-		err = errors.Errorf("url QueryUnescape error %v", err)
-		return
+		return nil, errors.Errorf("url QueryUnescape error %v", err)
 	}
 
-	// 加密字符串规则
 	buffer := bytes.NewBuffer(nil)
-	buffer.WriteString(path)
+	buffer.WriteString(req.Path)
 	buffer.WriteString(delimiter)
-	buffer.WriteString(methodName)
+	buffer.WriteString(strings.ToUpper(req.Method))
 	buffer.WriteString(delimiter)
 	buffer.WriteString(sortParamsEncode)
 	buffer.WriteString(delimiter)
 	buffer.WriteString(date)
 
-	// 对数据进行 sha256 加密，并进行 base64 encode
-	hash := hmac.New(sha256.New, []byte(s.secret))
-	hash.Write(buffer.Bytes())
-	digest := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	if len(auth.headers) > 0 {
+		buffer.WriteString(delimiter)
+		for i, name := range auth.headers {
+			if i > 0 {
+				buffer.WriteString("\n")
+			}
+			buffer.WriteString(name)
+			buffer.WriteString(":")
+			buffer.WriteString(strings.TrimSpace(req.Header.Get(name)))
+		}
+	}
 
-	authorization = fmt.Sprintf("%s %s", s.key, digest)
-	return
-}
+	switch auth.bodyDigest {
+	case "":
+	case BodyDigestSHA256:
+		sum := sha256.Sum256(req.Body)
+		buffer.WriteString(delimiter)
+		buffer.WriteString(hex.EncodeToString(sum[:]))
+	default:
+		return nil, errors.Errorf("unsupported body digest %s", auth.bodyDigest)
+	}
 
-func (s *signature) Verify(authorization, date string, path string, method string, params url.Values) (ok bool, err error) {
-	if date == "" {
-		err = errors.New("date required")
-		return
+	if auth.nonce != "" {
+		buffer.WriteString(delimiter)
+		buffer.WriteString(auth.nonce)
 	}
 
-	if path == "" {
-		err = errors.New("path required")
-		return
+	return buffer.Bytes(), nil
+}
+
+// canonicalHeaderNames 转为小写、去重并排序
+func canonicalHeaderNames(headers []string) []string {
+	if len(headers) == 0 {
+		return nil
 	}
 
-	if method == "" {
-		err = errors.New("method required")
-		return
+	seen := make(map[string]bool, len(headers))
+	names := make([]string, 0, len(headers))
+	for _, name := range headers {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
 	}
+	sort.Strings(names)
+	return names
+}
 
-	methodName := strings.ToUpper(method)
-	if !methods[methodName] {
-		err = errors.New("method param error")
-		return
+// authorizationHeader 签名头，格式为 "key digest"，
+// 非 HMAC 算法或对 body 摘要、header 签名时追加声明，
+// 例如 "key digest alg=ed25519,headers=content-type;x-request-id,body=sha256,nonce=..."
+type authorizationHeader struct {
+	key        string
+	digest     string
+	algorithm  string
+	headers    []string
+	bodyDigest string
+	nonce      string
+}
+
+func (a *authorizationHeader) String() string {
+	params := make([]string, 0, 4)
+	if a.algorithm != "" {
+		params = append(params, "alg="+a.algorithm)
+	}
+	if len(a.headers) > 0 {
+		params = append(params, "headers="+strings.Join(a.headers, ";"))
+	}
+	if a.bodyDigest != "" {
+		params = append(params, "body="+a.bodyDigest)
+	}
+	if a.nonce != "" {
+		params = append(params, "nonce="+a.nonce)
 	}
 
-	ts, err := timeutil.ParseCSTInLocation(date)
-	if err != nil {
-		err = errors.New("date must follow '2006-01-02 15:04:05'")
-		return
+	if len(params) == 0 {
+		return fmt.Sprintf("%s %s", a.key, a.digest)
 	}
+	return fmt.Sprintf("%s %s %s", a.key, a.digest, strings.Join(params, ","))
+}
 
-	if timeutil.SubInLocation(ts) > float64(s.ttl/time.Second) {
-		err = errors.Errorf("date exceeds limit %v", s.ttl)
-		return
+func parseAuthorization(authorization string) (*authorizationHeader, error) {
+	fields := strings.Fields(authorization)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, errors.New("authorization must follow 'key digest [params]'")
 	}
 
-	// Encode() 方法中自带 sorted by key
-	sortParamsEncode, err := url.QueryUnescape(params.Encode())
-	if err != nil {
-		err = errors.Errorf("url QueryUnescape error %v", err)
-		return
+	auth := &authorizationHeader{key: fields[0], digest: fields[1]}
+	if len(fields) == 2 {
+		return auth, nil
 	}
-	buffer := bytes.NewBuffer(nil)
-	buffer.WriteString(path)
-	buffer.WriteString(delimiter)
-	buffer.WriteString(methodName)
-	buffer.WriteString(delimiter)
-	buffer.WriteString(sortParamsEncode)
-	buffer.WriteString(delimiter)
-	buffer.WriteString(date)
 
-	// 对数据进行 hmac 加密，并进行 base64 encode
-	hash := hmac.New(sha256.New, []byte(s.secret))
-	hash.Write(buffer.Bytes())
-	digest := base64.StdEncoding.EncodeToString(hash.Sum(nil))
+	for _, param := range strings.Split(fields[2], ",") {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Errorf("authorization param %s error", param)
+		}
 
-	ok = authorization == fmt.Sprintf("%s %s", s.key, digest)
-	fmt.Println("authorization", authorization, "auth", fmt.Sprintf("%s %s", s.key, digest))
-	return
+		switch kv[0] {
+		case "alg":
+			if !algorithms[kv[1]] {
+				return nil, errors.Errorf("unsupported algorithm %s", kv[1])
+			}
+			auth.algorithm = kv[1]
+		case "headers":
+			headers := canonicalHeaderNames(strings.Split(kv[1], ";"))
+			if strings.Join(headers, ";") != kv[1] {
+				return nil, errors.New("authorization headers must be lowercase and sorted")
+			}
+			auth.headers = headers
+		case "body":
+			auth.bodyDigest = kv[1]
+		case "nonce":
+			auth.nonce = kv[1]
+		default:
+			return nil, errors.Errorf("unknown authorization param %s", kv[0])
+		}
+	}
+	return auth, nil
 }
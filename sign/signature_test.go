@@ -1,6 +1,7 @@
 package sign
 
 import (
+	"net/http"
 	"net/url"
 	"testing"
 	"time"
@@ -43,3 +44,98 @@ func TestSignature_Verify(t *testing.T) {
 	t.Log(ok)
 	t.Log(err)
 }
+
+func TestSignature_VerifyRequest(t *testing.T) {
+	signature := New(key, secret, ttl, WithBodyDigest(), WithSignedHeaders("Content-Type", "X-Request-Id"))
+
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("X-Request-Id", "b7f3c1")
+	req := &Request{
+		Path:   "/echo",
+		Method: "POST",
+		Params: url.Values{"a": {"a1"}},
+		Header: header,
+		Body:   []byte(`{"amount":100}`),
+	}
+
+	authorization, date, err := signature.GenerateRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("authorization:", authorization)
+
+	if ok, err := signature.VerifyRequest(authorization, date, req); err != nil || !ok {
+		t.Fatalf("verify failed: %v", err)
+	}
+
+	tampered := *req
+	tampered.Body = []byte(`{"amount":1}`)
+	if ok, _ := signature.VerifyRequest(authorization, date, &tampered); ok {
+		t.Fatal("tampered body should not pass")
+	}
+
+	// 未对 body 和 header 签名的请求不能通过要求签名的校验
+	authorization, date, _ = New(key, secret, ttl).GenerateRequest(req)
+	if _, err := signature.VerifyRequest(authorization, date, req); err == nil {
+		t.Fatal("uncovered body should not pass")
+	}
+}
+
+func TestSignature_Nonce(t *testing.T) {
+	signature := New(key, secret, ttl, WithNonceStore(NewMemoryNonceStore(100)))
+
+	params := url.Values{}
+	params.Add("a", "a1")
+	authorization, date, err := signature.Generate("/echo", "GET", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log("authorization:", authorization)
+
+	if ok, err := signature.Verify(authorization, date, "/echo", "GET", params); err != nil || !ok {
+		t.Fatalf("verify failed: %v", err)
+	}
+	if ok, err := signature.Verify(authorization, date, "/echo", "GET", params); ok || err == nil {
+		t.Fatal("replayed request should not pass")
+	}
+
+	// 不带 nonce 的签名不能通过要求 nonce 的校验
+	authorization, date, _ = New(key, secret, ttl).Generate("/echo", "GET", params)
+	if _, err := signature.Verify(authorization, date, "/echo", "GET", params); err == nil {
+		t.Fatal("signature without nonce should not pass")
+	}
+}
+
+func TestVerifier_Rotation(t *testing.T) {
+	registry := NewSecretRegistry()
+	registry.Add(key, Secret{Version: "v1", Value: secret})
+	registry.Add(key, Secret{Version: "v2", Value: "Zk3nQ8sVbT1xR7pLmC4wHy9eJ2uA6dGf"})
+	verifier := NewVerifier(registry, ttl)
+
+	params := url.Values{}
+	params.Add("a", "a1")
+
+	for version, value := range map[string]string{"v1": secret, "v2": "Zk3nQ8sVbT1xR7pLmC4wHy9eJ2uA6dGf"} {
+		authorization, date, _ := New(key, value, ttl).Generate("/echo", "GET", params)
+		result, err := verifier.Verify(authorization, date, "/echo", "GET", params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result.Key != key || result.SecretVersion != version {
+			t.Fatalf("unexpected result %+v, want version %s", result, version)
+		}
+	}
+
+	// 轮换完成后旧密钥失效
+	registry.Remove(key, "v1")
+	authorization, date, _ := New(key, secret, ttl).Generate("/echo", "GET", params)
+	if _, err := verifier.Verify(authorization, date, "/echo", "GET", params); err != ErrSignatureMismatch {
+		t.Fatalf("expect ErrSignatureMismatch, got %v", err)
+	}
+
+	authorization, date, _ = New("AK-unknown", secret, ttl).Generate("/echo", "GET", params)
+	if _, err := verifier.Verify(authorization, date, "/echo", "GET", params); err != ErrUnknownKey {
+		t.Fatalf("expect ErrUnknownKey, got %v", err)
+	}
+}
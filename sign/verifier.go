@@ -0,0 +1,251 @@
+package sign
+
+import (
+	"crypto"
+	"github.com/pkg/errors"
+	"net/url"
+	"sync"
+	"time"
+)
+
+var _ Verifier = (*verifier)(nil)
+
+var (
+	// ErrUnknownKey authorization 中的 key 没有可用的密钥
+	ErrUnknownKey = errors.New("unknown key")
+
+	// ErrSignatureMismatch 签名不一致
+	ErrSignatureMismatch = errors.New("signature mismatch")
+)
+
+// backendError SecretProvider、NonceStore 等服务端依赖返回的错误，不是调用方的签名问题
+type backendError struct {
+	err error
+}
+
+func (e *backendError) Error() string { return e.err.Error() }
+func (e *backendError) Unwrap() error { return e.err }
+
+// IsBackendError 验签失败是否由 SecretProvider、NonceStore 等服务端依赖的错误导致，如 redis、数据库错误
+func IsBackendError(err error) bool {
+	var be *backendError
+	return errors.As(err, &be)
+}
+
+// Secret 一个版本的密钥
+type Secret struct {
+	Version   string
+	Value     string           // HMAC 密钥
+	PublicKey crypto.PublicKey // 非对称算法的公钥，见 ParsePublicKeyPEM
+}
+
+// SecretProvider 根据 authorization 中的 key 查询密钥；
+// 轮换期间同时返回新旧多个版本，任意一个匹配即验证通过
+type SecretProvider interface {
+	Secrets(key string) ([]Secret, error)
+}
+
+// VerifyResult 验证通过的结果
+type VerifyResult struct {
+	Key           string // authorization 中的 key
+	SecretVersion string // 匹配的密钥版本
+}
+
+// Verifier 根据 authorization 中的 key 查找密钥并验证签名，用于验证多个调用方的请求
+type Verifier interface {
+
+	// Verify 验证签名，验证不通过时返回 error
+	Verify(authorization, date string, path string, method string, params url.Values) (*VerifyResult, error)
+
+	// VerifyRequest 验证签名，authorization 中声明的 body 摘要和 header 会一起校验
+	VerifyRequest(authorization, date string, req *Request) (*VerifyResult, error)
+}
+
+// SecretRegistry 内存中的密钥注册表，并发安全，可以在运行时添加和移除密钥版本以完成轮换
+type SecretRegistry struct {
+	mux     sync.RWMutex
+	secrets map[string][]Secret
+}
+
+// NewSecretRegistry 创建密钥注册表
+func NewSecretRegistry() *SecretRegistry {
+	return &SecretRegistry{secrets: make(map[string][]Secret)}
+}
+
+// Add 为 key 添加一个版本的密钥，版本已存在时覆盖
+func (r *SecretRegistry) Add(key string, secret Secret) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	secrets := r.secrets[key]
+	for i := range secrets {
+		if secrets[i].Version == secret.Version {
+			secrets[i] = secret
+			return
+		}
+	}
+	r.secrets[key] = append(secrets, secret)
+}
+
+// Remove 移除 key 的一个版本的密钥，轮换完成后移除旧版本
+func (r *SecretRegistry) Remove(key, version string) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	secrets := r.secrets[key]
+	remain := make([]Secret, 0, len(secrets))
+	for _, secret := range secrets {
+		if secret.Version != version {
+			remain = append(remain, secret)
+		}
+	}
+
+	if len(remain) == 0 {
+		delete(r.secrets, key)
+		return
+	}
+	r.secrets[key] = remain
+}
+
+// Secrets 返回 key 当前所有版本的密钥
+func (r *SecretRegistry) Secrets(key string) ([]Secret, error) {
+	r.mux.RLock()
+	defer r.mux.RUnlock()
+
+	secrets := make([]Secret, len(r.secrets[key]))
+	copy(secrets, r.secrets[key])
+	return secrets, nil
+}
+
+// singleSecret New 创建的 Signature 只有一个 key 和密钥
+type singleSecret struct {
+	key    string
+	secret Secret
+}
+
+func (s singleSecret) Secrets(key string) ([]Secret, error) {
+	if key != s.key {
+		return nil, nil
+	}
+	return []Secret{s.secret}, nil
+}
+
+type verifier struct {
+	provider SecretProvider
+	ttl      time.Duration
+	opt      *option
+}
+
+// NewVerifier 创建 Verifier，options 与 New 相同
+func NewVerifier(provider SecretProvider, ttl time.Duration, options ...Option) Verifier {
+	opt := new(option)
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	opt.signedHeaders = canonicalHeaderNames(opt.signedHeaders)
+
+	return newVerifier(provider, ttl, opt)
+}
+
+func newVerifier(provider SecretProvider, ttl time.Duration, opt *option) *verifier {
+	return &verifier{
+		provider: provider,
+		ttl:      ttl,
+		opt:      opt,
+	}
+}
+
+func (v *verifier) Verify(authorization, date string, path string, method string, params url.Values) (*VerifyResult, error) {
+	return v.VerifyRequest(authorization, date, &Request{Path: path, Method: method, Params: params})
+}
+
+func (v *verifier) VerifyRequest(authorization, date string, req *Request) (*VerifyResult, error) {
+	if date == "" {
+		return nil, errors.New("date required")
+	}
+
+	if err := checkRequest(req); err != nil {
+		return nil, err
+	}
+
+	ts, err := parseDate(date)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = checkDate(ts, v.ttl, v.opt); err != nil {
+		return nil, err
+	}
+
+	auth, err := parseAuthorization(authorization)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = v.checkCovered(auth); err != nil {
+		return nil, err
+	}
+
+	plain, err := canonicalString(req, date, auth)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets, err := v.provider.Secrets(auth.key)
+	if err != nil {
+		return nil, &backendError{err: errors.Wrapf(err, "get secrets of key %s error", auth.key)}
+	}
+	if len(secrets) == 0 {
+		return nil, ErrUnknownKey
+	}
+
+	var result *VerifyResult
+	for _, secret := range secrets {
+		if verifyDigest(auth.algorithm, secret, plain, auth.digest) {
+			result = &VerifyResult{Key: auth.key, SecretVersion: secret.Version}
+			break
+		}
+	}
+	if result == nil {
+		return nil, ErrSignatureMismatch
+	}
+
+	if v.opt.nonceStore == nil {
+		return result, nil
+	}
+
+	// 签名校验通过后再记录 nonce，避免伪造的请求占用 nonce；
+	// nonce 需要保留到签名时间的窗口结束
+	unused, err := v.opt.nonceStore.Add(auth.key, auth.nonce, dateWindow(v.ttl, v.opt))
+	if err != nil {
+		return nil, &backendError{err: err}
+	}
+	if !unused {
+		return nil, errors.New("nonce has been used")
+	}
+	return result, nil
+}
+
+// checkCovered 校验签名是否覆盖了要求的 body 摘要、header 和 nonce
+func (v *verifier) checkCovered(auth *authorizationHeader) error {
+	if v.opt.bodyDigest && auth.bodyDigest == "" {
+		return errors.New("body digest required")
+	}
+
+	if v.opt.nonceStore != nil && auth.nonce == "" {
+		return errors.New("nonce required")
+	}
+
+	signed := make(map[string]bool, len(auth.headers))
+	for _, name := range auth.headers {
+		signed[name] = true
+	}
+	for _, name := range v.opt.signedHeaders {
+		if !signed[name] {
+			return errors.Errorf("header %s must be signed", name)
+		}
+	}
+	return nil
+}
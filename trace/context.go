@@ -0,0 +1,29 @@
+package trace
+
+import (
+	"context"
+)
+
+type contextKey struct{}
+
+// NewContext 将 trace 存入 context，便于在调用链中传递
+func NewContext(ctx context.Context, t T) context.Context {
+	return context.WithValue(ctx, contextKey{}, t)
+}
+
+// FromContext 从 context 中获取 trace，不存在时返回 nil
+func FromContext(ctx context.Context) T {
+	if ctx == nil {
+		return nil
+	}
+	t, _ := ctx.Value(contextKey{}).(T)
+	return t
+}
+
+// IDFromContext 从 context 中获取 trace ID，不存在时返回空字符串
+func IDFromContext(ctx context.Context) string {
+	if t := FromContext(ctx); t != nil {
+		return t.ID()
+	}
+	return ""
+}
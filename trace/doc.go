@@ -0,0 +1,16 @@
+// Package trace 记录请求的链路信息，包括调用下游的 Dialog、SQL、Cache 和 span，
+// 支持 W3C Trace Context 传递、采样和 OTLP 导出。
+//
+// 一次请求的调用顺序：
+//
+//	t := trace.Extract(r.Header)            // 或 trace.New("")，读取上游链路
+//	sampler.Start(t)                        // 请求开始时决定是否采样
+//	ctx := trace.NewContext(r.Context(), t)
+//	...                                     // 处理请求，StartSpanFromContext、AppendSQL 等
+//	t.Success, t.CostMillisecond = ...
+//	sampler.Finish(t)                       // 请求结束时按失败、耗时等条件决定是否保留
+//	exporter.Export(t)                      // 只导出 Sampled 的 trace
+//
+// 必须先 Finish 再 Export，否则只有开始时被采样的 trace 会被导出；
+// 没有调用 Start 的 trace 在 Finish 时只按 Sampled 决定。
+package trace
@@ -0,0 +1,598 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// DefaultExporterBuffer 默认缓冲的 span 数，缓冲满时丢弃新 span
+	DefaultExporterBuffer = 8192
+	// DefaultExporterBatchSize 默认每批发送的 span 数
+	DefaultExporterBatchSize = 512
+	// DefaultExporterFlushInterval 默认发送间隔
+	DefaultExporterFlushInterval = 5 * time.Second
+	// DefaultExporterTimeout 默认每次发送的超时时间
+	DefaultExporterTimeout = 10 * time.Second
+
+	otlpScopeName = "github.com/phper95/pkg/trace"
+)
+
+// OTLP span 类型
+const (
+	otlpSpanKindInternal = 1
+	otlpSpanKindServer   = 2
+	otlpSpanKindClient   = 3
+	otlpSpanKindProducer = 4
+)
+
+// OTLP span 状态
+const (
+	otlpStatusOK    = 1
+	otlpStatusError = 2
+)
+
+// cst SQL、Cache 中 TraceTime 的时区
+var cst = time.FixedZone("CST", 8*3600)
+
+// ExporterOption OTLP 导出的配置
+type ExporterOption func(*exporterOption)
+
+type exporterOption struct {
+	serviceName   string
+	headers       map[string]string
+	buffer        int
+	batchSize     int
+	flushInterval time.Duration
+	timeout       time.Duration
+	errorHandler  func(err error)
+}
+
+// WithServiceName 设置 resource 的 service.name，默认为进程名
+func WithServiceName(name string) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.serviceName = name
+	}
+}
+
+// WithExporterHeader 设置发送请求的 header，如鉴权 token，可以调用多次
+func WithExporterHeader(key, value string) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.headers[key] = value
+	}
+}
+
+// WithExporterBuffer 设置缓冲的 span 数，缓冲满时丢弃新 span，不会阻塞业务；小于等于 0 时使用 DefaultExporterBuffer
+func WithExporterBuffer(size int) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.buffer = size
+	}
+}
+
+// WithExporterBatch 设置每批发送的最大 span 数和最长等待时间，小于等于 0 时使用默认值
+func WithExporterBatch(size int, interval time.Duration) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.batchSize = size
+		opt.flushInterval = interval
+	}
+}
+
+// WithExporterTimeout 设置每次发送的超时时间，小于等于 0 时使用 DefaultExporterTimeout
+func WithExporterTimeout(timeout time.Duration) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.timeout = timeout
+	}
+}
+
+// WithExporterErrorHandler 设置发送失败时的处理方法，如打印日志，默认输出到 stderr
+func WithExporterErrorHandler(handler func(err error)) ExporterOption {
+	return func(opt *exporterOption) {
+		opt.errorHandler = handler
+	}
+}
+
+// OTLPExporter 将 trace 转换为 OpenTelemetry span，以 OTLP/HTTP json 格式异步批量发送
+type OTLPExporter struct {
+	opt      *exporterOption
+	endpoint string
+	client   *http.Client
+	spans    chan *otlpSpan
+	dropped  uint64
+	failed   uint64
+
+	// mux 保护 closed，避免 Close 之后 Export 向已关闭的 spans 发送
+	mux    sync.RWMutex
+	closed bool
+	done   chan struct{}
+}
+
+// NewOTLPExporter 创建 OTLP 导出，endpoint 为 collector 的完整地址，如 http://127.0.0.1:4318/v1/traces
+func NewOTLPExporter(endpoint string, options ...ExporterOption) *OTLPExporter {
+	opt := &exporterOption{
+		headers:       make(map[string]string),
+		buffer:        DefaultExporterBuffer,
+		batchSize:     DefaultExporterBatchSize,
+		flushInterval: DefaultExporterFlushInterval,
+		timeout:       DefaultExporterTimeout,
+	}
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	if opt.serviceName == "" && len(os.Args) > 0 {
+		opt.serviceName = os.Args[0]
+	}
+	if opt.buffer <= 0 {
+		opt.buffer = DefaultExporterBuffer
+	}
+	if opt.batchSize <= 0 {
+		opt.batchSize = DefaultExporterBatchSize
+	}
+	if opt.flushInterval <= 0 {
+		opt.flushInterval = DefaultExporterFlushInterval
+	}
+	if opt.timeout <= 0 {
+		opt.timeout = DefaultExporterTimeout
+	}
+	if opt.errorHandler == nil {
+		opt.errorHandler = func(err error) {
+			fmt.Fprintf(os.Stderr, "%v\n", err)
+		}
+	}
+
+	e := &OTLPExporter{
+		opt:      opt,
+		endpoint: endpoint,
+		client:   &http.Client{Timeout: opt.timeout},
+		spans:    make(chan *otlpSpan, opt.buffer),
+		done:     make(chan struct{}),
+	}
+	go e.run()
+	return e
+}
+
+// Export 转换 trace 并放入缓冲，应在请求结束时(Sampler.Finish 之后)调用，未采样的 trace 不发送，
+// 缓冲满或已经 Close 时丢弃
+func (e *OTLPExporter) Export(t *Trace) {
+	if t == nil || !t.Sampled {
+		return
+	}
+
+	spans := convertTrace(t, time.Now())
+
+	e.mux.RLock()
+	defer e.mux.RUnlock()
+
+	if e.closed {
+		atomic.AddUint64(&e.dropped, uint64(len(spans)))
+		return
+	}
+	for _, span := range spans {
+		select {
+		case e.spans <- span:
+		default:
+			atomic.AddUint64(&e.dropped, 1)
+		}
+	}
+}
+
+// Close 停止接收 trace，发送完缓冲中的 span 后返回，之后 Export 的 span 计入 Dropped
+func (e *OTLPExporter) Close() error {
+	e.mux.Lock()
+	if !e.closed {
+		e.closed = true
+		close(e.spans)
+	}
+	e.mux.Unlock()
+
+	<-e.done
+	return nil
+}
+
+// Dropped 缓冲满或 Close 之后丢弃的 span 数
+func (e *OTLPExporter) Dropped() uint64 {
+	return atomic.LoadUint64(&e.dropped)
+}
+
+// Failed 发送失败的 span 数
+func (e *OTLPExporter) Failed() uint64 {
+	return atomic.LoadUint64(&e.failed)
+}
+
+func (e *OTLPExporter) run() {
+	defer close(e.done)
+
+	ticker := time.NewTicker(e.opt.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*otlpSpan, 0, e.opt.batchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := e.send(batch); err != nil {
+			atomic.AddUint64(&e.failed, uint64(len(batch)))
+			e.opt.errorHandler(fmt.Errorf("trace otlp export %d spans err: %w", len(batch), err))
+		}
+		batch = make([]*otlpSpan, 0, e.opt.batchSize)
+	}
+
+	for {
+		select {
+		case span, ok := <-e.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= e.opt.batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send 发送一批 span
+func (e *OTLPExporter) send(spans []*otlpSpan) error {
+	req := &otlpRequest{
+		ResourceSpans: []*otlpResourceSpans{{
+			Resource: otlpResource{Attributes: []otlpKeyValue{stringAttribute("service.name", e.opt.serviceName)}},
+			ScopeSpans: []*otlpScopeSpans{{
+				Scope: otlpScope{Name: otlpScopeName},
+				Spans: spans,
+			}},
+		}},
+	}
+	raw, err := json.Marshal(req)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, e.endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for key, value := range e.opt.headers {
+		httpReq.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("otlp collector response status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// otlpRequest OTLP ExportTraceServiceRequest 的 json 格式，ID 为十六进制字符串，64 位整数为字符串
+type otlpRequest struct {
+	ResourceSpans []*otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource      `json:"resource"`
+	ScopeSpans []*otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope   `json:"scope"`
+	Spans []*otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+type otlpAnyValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func stringAttribute(key, value string) otlpKeyValue {
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{StringValue: &value}}
+}
+
+func intAttribute(key string, value int64) otlpKeyValue {
+	s := strconv.FormatInt(value, 10)
+	return otlpKeyValue{Key: key, Value: otlpAnyValue{IntValue: &s}}
+}
+
+// attribute 按类型转换属性值，其它类型转换为 json 字符串
+func attribute(key string, value interface{}) otlpKeyValue {
+	switch v := value.(type) {
+	case string:
+		return stringAttribute(key, v)
+	case bool:
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{BoolValue: &v}}
+	case int:
+		return intAttribute(key, int64(v))
+	case int32:
+		return intAttribute(key, int64(v))
+	case int64:
+		return intAttribute(key, v)
+	case float32:
+		f := float64(v)
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{DoubleValue: &f}}
+	case float64:
+		return otlpKeyValue{Key: key, Value: otlpAnyValue{DoubleValue: &v}}
+	case fmt.Stringer:
+		return stringAttribute(key, v.String())
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return stringAttribute(key, fmt.Sprint(value))
+	}
+	return stringAttribute(key, string(raw))
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+func millisecond(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// traceTime 解析 SQL、Cache 的结束时间，解析失败时使用 fallback
+func traceTime(s string, fallback time.Time) time.Time {
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05", s, cst); err == nil {
+		return t
+	}
+	return fallback
+}
+
+// convertTrace 将 trace 转换为 span：入口为 server span，Dialog、SQL、Cache 为其子 span，
+// Debug 为入口 span 的 event；StartSpan 创建的 span 与对应的 Dialog 合并
+func convertTrace(t *Trace, end time.Time) []*otlpSpan {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	traceID := w3cTraceID(t.Identifier)
+	rootID := t.SpanID
+	if rootID == "" {
+		rootID = newSpanID()
+	}
+	start := end.Add(-millisecond(t.CostMillisecond))
+
+	root := &otlpSpan{
+		TraceID:           traceID,
+		SpanID:            rootID,
+		ParentSpanID:      t.ParentSpanID,
+		TraceState:        t.TraceState,
+		Name:              "request",
+		Kind:              otlpSpanKindServer,
+		StartTimeUnixNano: unixNano(start),
+		EndTimeUnixNano:   unixNano(end),
+		Attributes:        []otlpKeyValue{stringAttribute("trace.id", t.Identifier)},
+		Status:            otlpStatus{Code: otlpStatusOK},
+	}
+	if !t.Success {
+		root.Status.Code = otlpStatusError
+	}
+	if req := t.Request; req != nil {
+		root.Name = req.Method + " " + withoutQuery(req.DecodedURL)
+		root.Attributes = append(root.Attributes,
+			stringAttribute("http.method", req.Method),
+			stringAttribute("http.url", withoutQuery(req.DecodedURL)),
+		)
+	}
+	if resp := t.Response; resp != nil {
+		root.Attributes = append(root.Attributes, intAttribute("http.status_code", int64(resp.HttpCode)))
+		if resp.BusinessCode != 0 {
+			root.Attributes = append(root.Attributes, intAttribute("business_code", int64(resp.BusinessCode)))
+			root.Status.Message = resp.BusinessCodeMsg
+		}
+	}
+	for _, debug := range t.Debugs {
+		root.Events = append(root.Events, otlpEvent{
+			TimeUnixNano: unixNano(end),
+			Name:         debug.Key,
+			Attributes:   []otlpKeyValue{attribute("value", debug.Value)},
+		})
+	}
+
+	spans := []*otlpSpan{root}
+
+	dialogs := make(map[string]*Dialog)
+	for _, dialog := range t.ThirdPartyRequests {
+		if dialog.SpanID != "" {
+			dialogs[dialog.SpanID] = dialog
+		}
+	}
+
+	for _, s := range t.Spans {
+		span := convertSpan(s, traceID, end)
+		if dialog, ok := dialogs[s.SpanID]; ok {
+			span.Attributes = append(span.Attributes, dialogAttributes(dialog, true)...)
+			delete(dialogs, s.SpanID)
+		}
+		spans = append(spans, span)
+	}
+
+	// 没有对应 span 的 Dialog 没有开始时间，按请求开始时间计算
+	for _, dialog := range t.ThirdPartyRequests {
+		if dialog.SpanID != "" && dialogs[dialog.SpanID] == nil {
+			continue
+		}
+		span := &otlpSpan{
+			TraceID:           traceID,
+			SpanID:            newSpanID(),
+			ParentSpanID:      rootID,
+			Name:              "HTTP",
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: unixNano(start),
+			EndTimeUnixNano:   unixNano(start.Add(time.Duration(dialog.CostMillisecond) * time.Millisecond)),
+			Attributes:        dialogAttributes(dialog, false),
+			Status:            otlpStatus{Code: otlpStatusOK},
+		}
+		if req := dialog.Request; req != nil {
+			span.Name = "HTTP " + req.Method
+		}
+		if !dialog.Success {
+			span.Status.Code = otlpStatusError
+		}
+		spans = append(spans, span)
+	}
+
+	for _, sql := range t.SQLs {
+		sqlEnd := traceTime(sql.TraceTime, end)
+		span := &otlpSpan{
+			TraceID:           traceID,
+			SpanID:            newSpanID(),
+			ParentSpanID:      rootID,
+			Name:              "SQL",
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: unixNano(sqlEnd.Add(-time.Duration(sql.CostMillisecond) * time.Millisecond)),
+			EndTimeUnixNano:   unixNano(sqlEnd),
+			Attributes: []otlpKeyValue{
+				stringAttribute("db.statement", sql.SQL),
+				intAttribute("db.affected_rows", sql.AffectedRows),
+				stringAttribute("code.stack", sql.Stack),
+				attribute("slow", sql.SlowLoggerMillisecond > 0 && sql.CostMillisecond >= sql.SlowLoggerMillisecond),
+			},
+		}
+		spans = append(spans, span)
+	}
+
+	for _, cache := range t.Cache {
+		cacheEnd := traceTime(cache.TraceTime, end)
+		span := &otlpSpan{
+			TraceID:           traceID,
+			SpanID:            newSpanID(),
+			ParentSpanID:      rootID,
+			Name:              cache.Name + " " + cache.CMD,
+			Kind:              otlpSpanKindClient,
+			StartTimeUnixNano: unixNano(cacheEnd.Add(-time.Duration(cache.CostMillisecond) * time.Millisecond)),
+			EndTimeUnixNano:   unixNano(cacheEnd),
+			Attributes: []otlpKeyValue{
+				stringAttribute("db.system", cache.Name),
+				stringAttribute("db.operation", cache.CMD),
+				stringAttribute("cache.key", cache.Key),
+				attribute("slow", cache.SlowLoggerMillisecond > 0 && cache.CostMillisecond >= cache.SlowLoggerMillisecond),
+			},
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
+
+// convertSpan 转换 StartSpan 创建的 span，未结束的 span 使用 end 作为结束时间
+func convertSpan(s *Span, traceID string, end time.Time) *otlpSpan {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	kind := otlpSpanKindInternal
+	if _, ok := s.Attributes["http.method"]; ok {
+		kind = otlpSpanKindClient
+	} else if _, ok := s.Attributes["messaging.system"]; ok {
+		kind = otlpSpanKindProducer
+	}
+
+	spanEnd := s.EndTime
+	if spanEnd.IsZero() {
+		spanEnd = end
+	}
+
+	span := &otlpSpan{
+		TraceID:           traceID,
+		SpanID:            s.SpanID,
+		ParentSpanID:      s.ParentSpanID,
+		Name:              s.Name,
+		Kind:              kind,
+		StartTimeUnixNano: unixNano(s.StartTime),
+		EndTimeUnixNano:   unixNano(spanEnd),
+		Status:            otlpStatus{Code: otlpStatusOK},
+	}
+	for key, value := range s.Attributes {
+		span.Attributes = append(span.Attributes, attribute(key, value))
+	}
+	if s.Error != "" {
+		span.Status = otlpStatus{Code: otlpStatusError, Message: s.Error}
+	}
+	return span
+}
+
+// withoutQuery 去掉 url 中可能有敏感信息的 query
+func withoutQuery(url string) string {
+	if i := strings.IndexByte(url, '?'); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
+
+// dialogAttributes Dialog 的请求信息和重试次数，merged 为 true 时 span 中已有 method、url、状态码
+func dialogAttributes(dialog *Dialog, merged bool) []otlpKeyValue {
+	dialog.mux.Lock()
+	defer dialog.mux.Unlock()
+
+	attrs := []otlpKeyValue{
+		attribute("http.success", dialog.Success),
+		intAttribute("http.attempts", int64(len(dialog.Responses))),
+	}
+	if req := dialog.Request; req != nil {
+		attrs = append(attrs, stringAttribute("http.ttl", req.TTL))
+		if !merged {
+			attrs = append(attrs,
+				stringAttribute("http.method", req.Method),
+				stringAttribute("http.url", withoutQuery(req.DecodedURL)),
+			)
+		}
+	}
+	if n := len(dialog.Responses); n > 0 && !merged {
+		attrs = append(attrs, intAttribute("http.status_code", int64(dialog.Responses[n-1].HttpCode)))
+	}
+	return attrs
+}
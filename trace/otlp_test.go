@@ -0,0 +1,142 @@
+package trace
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// collectorStub 模拟 OTLP/HTTP collector，记录收到的请求
+type collectorStub struct {
+	mux      sync.Mutex
+	requests int
+	spans    []*otlpSpan
+	service  string
+	token    string
+}
+
+func (c *collectorStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req otlpRequest
+	if r.URL.Path != "/v1/traces" || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	c.requests++
+	c.token = r.Header.Get("Authorization")
+	for _, rs := range req.ResourceSpans {
+		c.service = *rs.Resource.Attributes[0].Value.StringValue
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	w.Write([]byte("{}"))
+}
+
+func TestOTLPExporter(t *testing.T) {
+	stub := new(collectorStub)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	tr := New("")
+	tr.WithRequest(&Request{Method: "GET", DecodedURL: "/user?id=1"})
+	tr.WithResponse(&Response{HttpCode: http.StatusOK})
+	tr.Success = true
+	tr.CostMillisecond = 20
+
+	span := tr.StartSpan("HTTP POST", nil)
+	span.SetAttribute("http.method", "POST").End()
+	tr.AppendDialog(&Dialog{SpanID: span.SpanID, Request: &Request{Method: "POST", TTL: "1s"}, Success: true})
+	tr.AppendDialog(&Dialog{Request: &Request{Method: "GET", DecodedURL: "http://api/x?token=1"}, CostMillisecond: 5})
+	tr.AppendSQL(&SQL{SQL: "select 1", TraceTime: "2023-05-17 10:00:00", CostMillisecond: 300, SlowLoggerMillisecond: 200})
+	tr.AppendCache(&Cache{Name: "redis", CMD: "get", Key: "k", CostMillisecond: 1})
+	tr.AppendDebug(&Debug{Key: "step", Value: map[string]int{"n": 1}})
+
+	exporter := NewOTLPExporter(srv.URL+"/v1/traces",
+		WithServiceName("user-api"),
+		WithExporterHeader("Authorization", "Bearer t"),
+		WithExporterBatch(2, time.Hour),
+	)
+	exporter.Export(tr)
+	exporter.Close()
+
+	if exporter.Failed() != 0 || exporter.Dropped() != 0 {
+		t.Fatalf("failed %d dropped %d", exporter.Failed(), exporter.Dropped())
+	}
+	if stub.requests != 3 || len(stub.spans) != 5 || stub.service != "user-api" || stub.token != "Bearer t" {
+		t.Fatalf("unexpected requests %d spans %d service %s token %s", stub.requests, len(stub.spans), stub.service, stub.token)
+	}
+
+	spans := make(map[string]*otlpSpan)
+	for _, s := range stub.spans {
+		if s.TraceID != tr.ID() {
+			t.Fatalf("unexpected trace id %s", s.TraceID)
+		}
+		spans[s.Name] = s
+	}
+
+	root := spans["GET /user"]
+	if root == nil || root.SpanID != tr.SpanID || root.Kind != otlpSpanKindServer || len(root.Events) != 1 {
+		t.Fatalf("unexpected root span %+v", root)
+	}
+	if s := spans["HTTP POST"]; s == nil || s.SpanID != span.SpanID || s.ParentSpanID != tr.SpanID || s.Kind != otlpSpanKindClient {
+		t.Fatalf("unexpected merged span %+v", s)
+	}
+	if s := spans["HTTP GET"]; s == nil || s.Status.Code != otlpStatusError {
+		t.Fatalf("unexpected dialog span %+v", s)
+	}
+	sql := spans["SQL"]
+	if sql == nil || sql.EndTimeUnixNano != "1684288800000000000" || sql.StartTimeUnixNano != "1684288799700000000" {
+		t.Fatalf("unexpected sql span %+v", sql)
+	}
+	if spans["redis get"] == nil {
+		t.Fatal("expect cache span")
+	}
+}
+
+func TestOTLPExporterFailed(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	var errs []error
+	exporter := NewOTLPExporter(srv.URL+"/v1/traces",
+		WithExporterBatch(10, time.Hour),
+		WithExporterErrorHandler(func(err error) {
+			errs = append(errs, err)
+		}),
+	)
+	exporter.Export(New(""))
+	exporter.Close()
+
+	if exporter.Failed() != 1 || len(errs) != 1 {
+		t.Fatalf("expect 1 failed span, got %d errs %v", exporter.Failed(), errs)
+	}
+}
+
+func TestOTLPExporterExportAfterClose(t *testing.T) {
+	stub := new(collectorStub)
+	srv := httptest.NewServer(stub)
+	defer srv.Close()
+
+	// 非法的配置使用默认值
+	exporter := NewOTLPExporter(srv.URL+"/v1/traces", WithExporterBuffer(-1), WithExporterBatch(-1, 0))
+	exporter.Close()
+	exporter.Close()
+
+	exporter.Export(New(""))
+	if exporter.Dropped() != 1 || stub.requests != 0 {
+		t.Fatalf("unexpected dropped %d requests %d", exporter.Dropped(), stub.requests)
+	}
+}
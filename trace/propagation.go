@@ -0,0 +1,146 @@
+package trace
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HeaderTraceparent W3C Trace Context 的 traceparent header
+	HeaderTraceparent = "traceparent"
+	// HeaderTracestate W3C Trace Context 的 tracestate header
+	HeaderTracestate = "tracestate"
+
+	traceparentVersion    = "00"
+	traceparentSampled    = "01"
+	traceparentNotSampled = "00"
+)
+
+// Carrier 传递链路信息的载体，如 http.Header、kafka 消息 header
+type Carrier interface {
+	Get(key string) string
+	Set(key, value string)
+}
+
+// isHex 是否为小写十六进制字符串
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// isZero 是否全为 0，W3C 规定全 0 的 ID 无效
+func isZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+// w3cTraceID 转换为 32 位十六进制的 trace-id：较短的十六进制 ID 左侧补 0，其它 ID 取 sha256 的前 16 字节
+func w3cTraceID(id string) string {
+	lower := strings.ToLower(id)
+	if len(lower) > 0 && len(lower) <= 32 && isHex(lower) && !isZero(lower) {
+		return strings.Repeat("0", 32-len(lower)) + lower
+	}
+
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:16])
+}
+
+// FormatTraceparent 生成 traceparent，如 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01
+func FormatTraceparent(traceID, spanID string) string {
+	return formatTraceparent(traceID, spanID, true)
+}
+
+func formatTraceparent(traceID, spanID string, sampled bool) string {
+	flags := traceparentSampled
+	if !sampled {
+		flags = traceparentNotSampled
+	}
+	return traceparentVersion + "-" + w3cTraceID(traceID) + "-" + spanID + "-" + flags
+}
+
+// ParseTraceparent 解析 traceparent，返回 trace-id 和父 span ID，格式错误时 ok 为 false
+func ParseTraceparent(s string) (traceID, spanID string, ok bool) {
+	traceID, spanID, _, ok = parseTraceparent(s)
+	return
+}
+
+// parseTraceparent 解析 traceparent，sampled 为 trace-flags 的采样位
+func parseTraceparent(s string) (traceID, spanID string, sampled, ok bool) {
+	parts := strings.Split(strings.TrimSpace(s), "-")
+	if len(parts) < 4 {
+		return "", "", false, false
+	}
+
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if len(version) != 2 || !isHex(version) || version == "ff" {
+		return "", "", false, false
+	}
+	// 版本 00 只能有 4 段，更高版本可能追加字段
+	if version == traceparentVersion && len(parts) != 4 {
+		return "", "", false, false
+	}
+	if len(traceID) != 32 || !isHex(traceID) || isZero(traceID) {
+		return "", "", false, false
+	}
+	if len(spanID) != 16 || !isHex(spanID) || isZero(spanID) {
+		return "", "", false, false
+	}
+	if len(flags) != 2 || !isHex(flags) {
+		return "", "", false, false
+	}
+	flag, _ := strconv.ParseUint(flags, 16, 8)
+	return traceID, spanID, flag&1 == 1, true
+}
+
+// Inject 将链路信息写入 carrier，span 为 nil 时使用 trace 的入口 span；
+// 同时写入 Header 保留原始的 trace ID
+func Inject(carrier Carrier, t *Trace, span *Span) {
+	if carrier == nil || t == nil {
+		return
+	}
+
+	spanID := t.SpanID
+	if span != nil {
+		spanID = span.SpanID
+	}
+	if spanID == "" {
+		spanID = newSpanID()
+	}
+
+	carrier.Set(Header, t.ID())
+	carrier.Set(HeaderTraceparent, formatTraceparent(t.ID(), spanID, t.Sampled))
+	if t.TraceState != "" {
+		carrier.Set(HeaderTracestate, t.TraceState)
+	}
+}
+
+// Extract 从 carrier 中读取上游的链路信息并创建 trace，没有链路信息时创建新的 trace；
+// 优先使用 Header 中的原始 trace ID，保证与上游 ID 一致；Sampled 使用上游的采样标记
+func Extract(carrier Carrier) *Trace {
+	if carrier == nil {
+		return New("")
+	}
+
+	traceID, parentID, sampled, ok := parseTraceparent(carrier.Get(HeaderTraceparent))
+	if id := carrier.Get(Header); id != "" {
+		// 原始 ID 与 traceparent 不是同一条链路时，不使用 traceparent 的父 span
+		if ok && w3cTraceID(id) != traceID {
+			parentID = ""
+		}
+		traceID = id
+	}
+
+	t := New(traceID)
+	t.ParentSpanID = parentID
+	if parentID != "" {
+		t.TraceState = carrier.Get(HeaderTracestate)
+		t.Sampled = sampled
+	}
+	return t
+}
@@ -0,0 +1,77 @@
+package trace
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	traceID, spanID, ok := ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || spanID != "00f067aa0ba902b7" {
+		t.Fatalf("unexpected %s %s %v", traceID, spanID, ok)
+	}
+
+	for _, s := range []string{
+		"",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, _, ok := ParseTraceparent(s); ok {
+			t.Fatalf("expect invalid traceparent %q", s)
+		}
+	}
+}
+
+func TestInjectExtract(t *testing.T) {
+	up := New("")
+	up.TraceState = "vendor=abc"
+	span := up.StartSpan("GET /user", nil)
+
+	header := http.Header{}
+	Inject(header, up, span)
+
+	down := Extract(header)
+	if down.ID() != up.ID() || down.ParentSpanID != span.SpanID || down.TraceState != "vendor=abc" {
+		t.Fatalf("unexpected trace %+v", down)
+	}
+	if span.ParentSpanID != up.SpanID || span.TraceID != up.ID() {
+		t.Fatalf("unexpected span %+v", span)
+	}
+
+	// 非十六进制的 trace ID 通过 Header 原样传递
+	custom := New("order-123")
+	header = http.Header{}
+	Inject(header, custom, nil)
+	if down := Extract(header); down.ID() != "order-123" || down.ParentSpanID != custom.SpanID {
+		t.Fatalf("unexpected trace %+v", down)
+	}
+
+	if fresh := Extract(http.Header{}); fresh.ID() == "" || fresh.ParentSpanID != "" {
+		t.Fatalf("unexpected trace %+v", fresh)
+	}
+}
+
+func TestStartSpanFromContext(t *testing.T) {
+	tr := New("")
+	ctx := NewContext(context.Background(), tr)
+
+	parent, ctx := StartSpanFromContext(ctx, "parent")
+	child, _ := StartSpanFromContext(ctx, "child")
+	child.SetAttribute("k", "v").End()
+	parent.End()
+
+	if parent.ParentSpanID != tr.SpanID || child.ParentSpanID != parent.SpanID || len(tr.Spans) != 2 {
+		t.Fatalf("unexpected spans %+v", tr.Spans)
+	}
+	if child.EndTime.IsZero() || child.Attributes["k"] != "v" {
+		t.Fatalf("unexpected child %+v", child)
+	}
+
+	if span, _ := StartSpanFromContext(context.Background(), "none"); span != nil {
+		t.Fatal("expect nil span without trace")
+	}
+}
@@ -0,0 +1,55 @@
+package trace
+
+import (
+	"sync/atomic"
+)
+
+// Redactor 脱敏规则，logger/redact 包的 *redact.Redactor 实现了该接口
+type Redactor interface {
+	Value(key string, v interface{}) interface{}
+}
+
+// redactorHolder atomic.Value 只能存储相同的具体类型
+type redactorHolder struct {
+	Redactor
+}
+
+// redactor 全局脱敏规则，为空时不脱敏
+var redactor atomic.Value
+
+// SetRedactor 设置记录 Request、Response 时的脱敏规则，例如 SetRedactor(redact.Default())；
+// 脱敏作用于 WithRequest、WithResponse、AppendDialog、AppendResponse 记录的 Header 和 Body
+func SetRedactor(r Redactor) {
+	redactor.Store(redactorHolder{r})
+}
+
+func getRedactor() Redactor {
+	h, _ := redactor.Load().(redactorHolder)
+	return h.Redactor
+}
+
+// redactRequest 返回 Header 和 Body 脱敏后的副本
+func redactRequest(req *Request) *Request {
+	r := getRedactor()
+	if r == nil || req == nil {
+		return req
+	}
+
+	redacted := *req
+	redacted.Header = r.Value("", req.Header)
+	redacted.Body = r.Value("", req.Body)
+	return &redacted
+}
+
+// redactResponse 返回 Header 和 Body 脱敏后的副本
+func redactResponse(resp *Response) *Response {
+	r := getRedactor()
+	if r == nil || resp == nil {
+		return resp
+	}
+
+	redacted := *resp
+	redacted.Header = r.Value("", resp.Header)
+	redacted.Body = r.Value("", resp.Body)
+	return &redacted
+}
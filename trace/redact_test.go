@@ -0,0 +1,43 @@
+package trace
+
+import (
+	"net/http"
+	"testing"
+)
+
+// maskRedactor 将 Authorization header 和 Body 替换为 ***
+type maskRedactor struct{}
+
+func (maskRedactor) Value(key string, v interface{}) interface{} {
+	if h, ok := v.(http.Header); ok {
+		masked := h.Clone()
+		if masked.Get("Authorization") != "" {
+			masked.Set("Authorization", "***")
+		}
+		return masked
+	}
+	if v == nil {
+		return nil
+	}
+	return "***"
+}
+
+func TestRedact(t *testing.T) {
+	SetRedactor(maskRedactor{})
+	defer SetRedactor(nil)
+
+	header := http.Header{"Authorization": {"Bearer xxx"}}
+	tr := New("").WithRequest(&Request{Header: header, Body: `{"password":"123","name":"tom"}`})
+
+	if tr.Request.Header.(http.Header).Get("Authorization") != "***" || header.Get("Authorization") != "Bearer xxx" {
+		t.Fatalf("unexpected header %v", tr.Request.Header)
+	}
+	if tr.Request.Body != "***" {
+		t.Fatalf("unexpected body %v", tr.Request.Body)
+	}
+
+	SetRedactor(nil)
+	if tr := New("").WithRequest(&Request{Body: "raw"}); tr.Request.Body != "raw" {
+		t.Fatalf("unexpected body %v", tr.Request.Body)
+	}
+}
@@ -0,0 +1,233 @@
+package trace
+
+import (
+	"container/list"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultSamplerBuffer 默认最多等待结束时决定的 trace 数
+const DefaultSamplerBuffer = 10000
+
+// 保留 trace 的原因
+const (
+	SampleReasonNone    = ""
+	SampleReasonHead    = "head"       // 请求开始时按比例采样、AlwaysTrace 或上游已采样
+	SampleReasonError   = "error"      // 请求失败
+	SampleReasonLatency = "latency"    // 超过耗时阈值
+	SampleReasonSQL     = "slow_sql"   // 有慢 SQL
+	SampleReasonCache   = "slow_cache" // 有慢 Cache 操作
+)
+
+// SamplerOption 采样配置
+type SamplerOption func(*samplerOption)
+
+type samplerOption struct {
+	rate             float64
+	latencyThreshold time.Duration
+	keepErrors       bool
+	keepSlowEntries  bool
+	buffer           int
+}
+
+// WithSampleRate 请求开始时按比例采样，取值 0 到 1，默认 0 只保留满足结束时条件的 trace
+func WithSampleRate(rate float64) SamplerOption {
+	return func(opt *samplerOption) {
+		opt.rate = rate
+	}
+}
+
+// WithLatencyThreshold 保留耗时大于等于 d 的 trace，默认不按耗时保留
+func WithLatencyThreshold(d time.Duration) SamplerOption {
+	return func(opt *samplerOption) {
+		opt.latencyThreshold = d
+	}
+}
+
+// WithKeepErrors 是否保留失败(Success 为 false)的 trace，默认保留
+func WithKeepErrors(keep bool) SamplerOption {
+	return func(opt *samplerOption) {
+		opt.keepErrors = keep
+	}
+}
+
+// WithKeepSlowEntries 是否保留耗时超过 SlowLoggerMillisecond 的 SQL、Cache 的 trace，默认保留
+func WithKeepSlowEntries(keep bool) SamplerOption {
+	return func(opt *samplerOption) {
+		opt.keepSlowEntries = keep
+	}
+}
+
+// WithSamplerBuffer 最多等待结束时决定的 trace 数，超过时最早的 trace 只按开始时的决定处理；
+// 小于等于 0 时使用 DefaultSamplerBuffer
+func WithSamplerBuffer(size int) SamplerOption {
+	return func(opt *samplerOption) {
+		opt.buffer = size
+	}
+}
+
+// Sampler 采样策略：请求开始时调用 Start 按比例决定，请求结束时调用 Finish，
+// 未被采样的 trace 满足失败、慢请求、慢 SQL/Cache 等条件时仍然保留
+type Sampler struct {
+	opt *samplerOption
+
+	mux     sync.Mutex
+	pending map[*Trace]*list.Element
+	order   *list.List
+
+	kept    uint64
+	dropped uint64
+	evicted uint64
+}
+
+// pendingTrace 等待结束时决定的 trace
+type pendingTrace struct {
+	trace *Trace
+	start time.Time
+}
+
+// NewSampler 创建采样策略
+func NewSampler(options ...SamplerOption) *Sampler {
+	opt := &samplerOption{
+		keepErrors:      true,
+		keepSlowEntries: true,
+		buffer:          DefaultSamplerBuffer,
+	}
+	for _, f := range options {
+		if f != nil {
+			f(opt)
+		}
+	}
+	if opt.buffer <= 0 {
+		opt.buffer = DefaultSamplerBuffer
+	}
+
+	return &Sampler{
+		opt:     opt,
+		pending: make(map[*Trace]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// Start 请求开始时决定是否采样并设置 t.Sampled：AlwaysTrace、上游已采样或按比例命中时采样，
+// 未采样的 trace 放入缓冲等待 Finish
+func (s *Sampler) Start(t *Trace) {
+	if t == nil {
+		return
+	}
+
+	// 上游已采样时保持一致，保证整条链路完整
+	upstream := t.ParentSpanID != "" && t.Sampled
+	t.Sampled = t.AlwaysTrace || upstream || s.opt.rate >= 1 || s.opt.rate > 0 && rand.Float64() < s.opt.rate
+
+	if t.Sampled {
+		return
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if _, ok := s.pending[t]; ok {
+		return
+	}
+	s.pending[t] = s.order.PushBack(&pendingTrace{trace: t, start: time.Now()})
+
+	// 缓冲满时淘汰最早的 trace
+	for s.order.Len() > s.opt.buffer {
+		front := s.order.Front()
+		s.order.Remove(front)
+		delete(s.pending, front.Value.(*pendingTrace).trace)
+		atomic.AddUint64(&s.evicted, 1)
+	}
+}
+
+// Finish 请求结束时决定是否保留 trace，返回保留的原因，不保留时返回 SampleReasonNone；
+// 同时更新 t.Sampled，没有调用 Start 或已被淘汰的 trace 只按 Sampled 决定
+func (s *Sampler) Finish(t *Trace) string {
+	if t == nil {
+		return SampleReasonNone
+	}
+
+	s.mux.Lock()
+	elem, ok := s.pending[t]
+	if ok {
+		s.order.Remove(elem)
+		delete(s.pending, t)
+	}
+	s.mux.Unlock()
+
+	reason := SampleReasonNone
+	switch {
+	case t.Sampled || t.AlwaysTrace:
+		reason = SampleReasonHead
+	case ok:
+		reason = s.tailReason(t, elem.Value.(*pendingTrace).start)
+	}
+
+	t.Sampled = reason != SampleReasonNone
+	if t.Sampled {
+		atomic.AddUint64(&s.kept, 1)
+	} else {
+		atomic.AddUint64(&s.dropped, 1)
+	}
+	return reason
+}
+
+// tailReason 按请求结果决定是否保留，没有设置 CostMillisecond 时使用 Start 到现在的耗时
+func (s *Sampler) tailReason(t *Trace, start time.Time) string {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	if s.opt.keepErrors && !t.Success {
+		return SampleReasonError
+	}
+
+	if s.opt.latencyThreshold > 0 {
+		cost := millisecond(t.CostMillisecond)
+		if cost <= 0 {
+			cost = time.Since(start)
+		}
+		if cost >= s.opt.latencyThreshold {
+			return SampleReasonLatency
+		}
+	}
+
+	if s.opt.keepSlowEntries {
+		for _, sql := range t.SQLs {
+			if sql.SlowLoggerMillisecond > 0 && sql.CostMillisecond >= sql.SlowLoggerMillisecond {
+				return SampleReasonSQL
+			}
+		}
+		for _, cache := range t.Cache {
+			if cache.SlowLoggerMillisecond > 0 && cache.CostMillisecond >= cache.SlowLoggerMillisecond {
+				return SampleReasonCache
+			}
+		}
+	}
+	return SampleReasonNone
+}
+
+// Pending 等待结束时决定的 trace 数
+func (s *Sampler) Pending() int {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	return s.order.Len()
+}
+
+// Kept 保留的 trace 数
+func (s *Sampler) Kept() uint64 {
+	return atomic.LoadUint64(&s.kept)
+}
+
+// Dropped 丢弃的 trace 数
+func (s *Sampler) Dropped() uint64 {
+	return atomic.LoadUint64(&s.dropped)
+}
+
+// Evicted 缓冲满时被淘汰、只按开始时决定的 trace 数
+func (s *Sampler) Evicted() uint64 {
+	return atomic.LoadUint64(&s.evicted)
+}
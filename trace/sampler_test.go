@@ -0,0 +1,102 @@
+package trace
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSampler(t *testing.T) {
+	s := NewSampler(WithLatencyThreshold(time.Second))
+
+	ok := New("")
+	ok.Success = true
+	s.Start(ok)
+	if ok.Sampled || s.Pending() != 1 {
+		t.Fatalf("expect pending unsampled trace")
+	}
+	if reason := s.Finish(ok); reason != SampleReasonNone || ok.Sampled {
+		t.Fatalf("unexpected reason %q", reason)
+	}
+
+	cases := map[string]func(tr *Trace){
+		SampleReasonError:   func(tr *Trace) { tr.Success = false },
+		SampleReasonLatency: func(tr *Trace) { tr.CostMillisecond = 1500 },
+		SampleReasonSQL:     func(tr *Trace) { tr.AppendSQL(&SQL{CostMillisecond: 300, SlowLoggerMillisecond: 200}) },
+		SampleReasonCache:   func(tr *Trace) { tr.AppendCache(&Cache{CostMillisecond: 50, SlowLoggerMillisecond: 10}) },
+	}
+	for expect, f := range cases {
+		tr := New("")
+		tr.Success = true
+		s.Start(tr)
+		f(tr)
+		if reason := s.Finish(tr); reason != expect || !tr.Sampled {
+			t.Fatalf("expect %q, got %q", expect, reason)
+		}
+	}
+
+	always := New("")
+	always.SetAlwaysTrace(true)
+	always.Success = true
+	s.Start(always)
+	if reason := s.Finish(always); reason != SampleReasonHead {
+		t.Fatalf("expect head, got %q", reason)
+	}
+	if always.SetAlwaysTrace(false); always.AlwaysTrace {
+		t.Fatal("SetAlwaysTrace(false) should disable always trace")
+	}
+
+	if s.Pending() != 0 || s.Kept() != 5 || s.Dropped() != 1 {
+		t.Fatalf("unexpected pending %d kept %d dropped %d", s.Pending(), s.Kept(), s.Dropped())
+	}
+}
+
+func TestSamplerBuffer(t *testing.T) {
+	s := NewSampler(WithSamplerBuffer(2))
+
+	traces := []*Trace{New(""), New(""), New("")}
+	for _, tr := range traces {
+		s.Start(tr)
+	}
+	if s.Pending() != 2 || s.Evicted() != 1 {
+		t.Fatalf("unexpected pending %d evicted %d", s.Pending(), s.Evicted())
+	}
+
+	// 被淘汰的 trace 只按开始时的决定处理
+	if reason := s.Finish(traces[0]); reason != SampleReasonNone {
+		t.Fatalf("expect evicted trace dropped, got %q", reason)
+	}
+	if reason := s.Finish(traces[1]); reason != SampleReasonError {
+		t.Fatalf("expect error, got %q", reason)
+	}
+
+	// 小于等于 0 时使用默认值
+	if s := NewSampler(WithSamplerBuffer(-1)); s.opt.buffer != DefaultSamplerBuffer {
+		t.Fatalf("unexpected buffer %d", s.opt.buffer)
+	}
+}
+
+func TestSamplerParentBased(t *testing.T) {
+	s := NewSampler()
+
+	up := New("")
+	header := http.Header{}
+	Inject(header, up, nil)
+	down := Extract(header)
+	s.Start(down)
+	if !down.Sampled {
+		t.Fatal("expect sampled when upstream sampled")
+	}
+
+	up.Sampled = false
+	Inject(header, up, nil)
+	if !strings.HasSuffix(header.Get(HeaderTraceparent), "-00") {
+		t.Fatalf("unexpected traceparent %s", header.Get(HeaderTraceparent))
+	}
+	down = Extract(header)
+	s.Start(down)
+	if down.Sampled {
+		t.Fatal("expect unsampled when upstream unsampled")
+	}
+}
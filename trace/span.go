@@ -0,0 +1,126 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"sync"
+	"time"
+)
+
+// Span 链路中的一个操作，如一次 http 调用、一次消息发送
+type Span struct {
+	mux          sync.Mutex
+	TraceID      string                 `json:"trace_id"`                 // 链路ID
+	SpanID       string                 `json:"span_id"`                  // 16 位十六进制的 span ID
+	ParentSpanID string                 `json:"parent_span_id,omitempty"` // 父 span ID
+	Name         string                 `json:"name"`                     // 操作名
+	StartTime    time.Time              `json:"start_time"`               // 开始时间
+	EndTime      time.Time              `json:"end_time"`                 // 结束时间
+	Attributes   map[string]interface{} `json:"attributes,omitempty"`     // 属性
+	Error        string                 `json:"error,omitempty"`          // 错误信息
+}
+
+// newSpanID 生成 8 字节的 span ID
+func newSpanID() string {
+	buf := make([]byte, 8)
+	io.ReadFull(rand.Reader, buf)
+	return hex.EncodeToString(buf)
+}
+
+// StartSpan 开始一个 span 并追加到 trace，parent 为 nil 时父 span 为当前服务的入口 span
+func (t *Trace) StartSpan(name string, parent *Span) *Span {
+	parentID := t.SpanID
+	if parent != nil {
+		parentID = parent.SpanID
+	}
+
+	span := &Span{
+		TraceID:      t.Identifier,
+		SpanID:       newSpanID(),
+		ParentSpanID: parentID,
+		Name:         name,
+		StartTime:    time.Now(),
+	}
+
+	t.mux.Lock()
+	defer t.mux.Unlock()
+
+	t.Spans = append(t.Spans, span)
+	return span
+}
+
+// SetAttribute 设置属性
+func (s *Span) SetAttribute(key string, value interface{}) *Span {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.Attributes == nil {
+		s.Attributes = make(map[string]interface{})
+	}
+	s.Attributes[key] = value
+	return s
+}
+
+// SetError 记录错误，err 为 nil 时忽略
+func (s *Span) SetError(err error) *Span {
+	if err == nil {
+		return s
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	s.Error = err.Error()
+	return s
+}
+
+// End 结束 span，重复调用时只记录第一次的结束时间
+func (s *Span) End() {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.EndTime.IsZero() {
+		s.EndTime = time.Now()
+	}
+}
+
+// Duration span 的执行时长，未结束时返回 0
+func (s *Span) Duration() time.Duration {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if s.EndTime.IsZero() {
+		return 0
+	}
+	return s.EndTime.Sub(s.StartTime)
+}
+
+type spanContextKey struct{}
+
+// ContextWithSpan 将 span 存入 context，之后 StartSpanFromContext 创建的 span 以它为父 span
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, span)
+}
+
+// SpanFromContext 从 context 中获取 span，不存在时返回 nil
+func SpanFromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanContextKey{}).(*Span)
+	return span
+}
+
+// StartSpanFromContext 使用 context 中的 trace 和父 span 开始一个 span，并返回带有新 span 的 context；
+// context 中没有 *Trace 时返回 nil span
+func StartSpanFromContext(ctx context.Context, name string) (*Span, context.Context) {
+	t, ok := FromContext(ctx).(*Trace)
+	if !ok || t == nil {
+		return nil, ctx
+	}
+
+	span := t.StartSpan(name, SpanFromContext(ctx))
+	return span, ContextWithSpan(ctx, span)
+}
@@ -25,16 +25,21 @@ type T interface {
 type Trace struct {
 	mux                sync.Mutex
 	Identifier         string      `json:"trace_id"`             // 链路ID
+	SpanID             string      `json:"span_id"`              // 当前服务入口 span ID
+	ParentSpanID       string      `json:"parent_span_id"`       // 上游服务的 span ID
+	TraceState         string      `json:"trace_state"`          // W3C tracestate，原样向下游传递
 	Request            *Request    `json:"request"`              // 请求信息
 	Response           *Response   `json:"response"`             // 返回信息
 	ThirdPartyRequests []*Dialog   `json:"third_party_requests"` // 调用第三方接口的信息
 	Debugs             []*Debug    `json:"debugs"`               // 调试信息
 	SQLs               []*SQL      `json:"sqls"`                 // 执行的 SQL 信息
 	Cache              []*Cache    `json:"Cache"`                // 执行的 Cache 信息
+	Spans              []*Span     `json:"spans"`                // 内部操作的 span
 	Success            bool        `json:"success"`              // 请求结果 true or false
 	CostMillisecond    float64     `json:"cost_millisecond"`     // 执行时长(单位ms)
 	Logger             *zap.Logger `json:"-"`
 	AlwaysTrace        bool        `json:"always_trace"`
+	Sampled            bool        `json:"sampled"` // 是否记录，Sampler 在请求开始和结束时决定
 }
 
 // Request 请求信息
@@ -92,10 +97,11 @@ type D interface {
 // 内部调用其它方接口的会话信息；失败时会有retry操作，所以 response 会有多次。
 type Dialog struct {
 	mux             sync.Mutex
-	Request         *Request    `json:"request"`          // 请求信息
-	Responses       []*Response `json:"responses"`        // 返回信息
-	Success         bool        `json:"success"`          // 是否成功，true 或 false
-	CostMillisecond int64       `json:"cost_millisecond"` // 执行时长(单位ms)
+	SpanID          string      `json:"span_id,omitempty"` // 对应的 span ID
+	Request         *Request    `json:"request"`           // 请求信息
+	Responses       []*Response `json:"responses"`         // 返回信息
+	Success         bool        `json:"success"`           // 是否成功，true 或 false
+	CostMillisecond int64       `json:"cost_millisecond"`  // 执行时长(单位ms)
 	Logger          *zap.Logger `json:"-"`
 	AlwaysTrace     bool        `json:"always_trace"`
 }
@@ -107,7 +113,7 @@ func (d *Dialog) AppendResponse(resp *Response) {
 	}
 
 	d.mux.Lock()
-	d.Responses = append(d.Responses, resp)
+	d.Responses = append(d.Responses, redactResponse(resp))
 	d.mux.Unlock()
 }
 
@@ -121,13 +127,16 @@ type Debug struct {
 
 func New(id string) *Trace {
 	if id == "" {
-		buf := make([]byte, 10)
+		// 16 字节，与 W3C traceparent 的 trace-id 一致
+		buf := make([]byte, 16)
 		io.ReadFull(rand.Reader, buf)
 		id = hex.EncodeToString(buf)
 	}
 
 	return &Trace{
 		Identifier: id,
+		SpanID:     newSpanID(),
+		Sampled:    true,
 	}
 }
 
@@ -138,13 +147,13 @@ func (t *Trace) ID() string {
 
 // WithRequest 设置request
 func (t *Trace) WithRequest(req *Request) *Trace {
-	t.Request = req
+	t.Request = redactRequest(req)
 	return t
 }
 
 // WithResponse 设置response
 func (t *Trace) WithResponse(resp *Response) *Trace {
-	t.Response = resp
+	t.Response = redactResponse(resp)
 	return t
 }
 
@@ -154,6 +163,10 @@ func (t *Trace) AppendDialog(dialog *Dialog) *Trace {
 		return t
 	}
 
+	dialog.mux.Lock()
+	dialog.Request = redactRequest(dialog.Request)
+	dialog.mux.Unlock()
+
 	t.mux.Lock()
 	defer t.mux.Unlock()
 
@@ -194,7 +207,7 @@ func (t *Trace) SetLogger(logger *zap.Logger) {
 
 //始终记录trace信息
 func (t *Trace) SetAlwaysTrace(b bool) {
-	t.AlwaysTrace = true
+	t.AlwaysTrace = b
 }
 
 // AppendCache 追加 Cache